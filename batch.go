@@ -0,0 +1,133 @@
+package eventstorage
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+)
+
+// WriteBatch appends events all-or-nothing and returns offset of the first one.
+// Batch is never split between events files or flushes, after crash it is either fully kept or dropped.
+// Framed events of batch are marked by flag of their length. Lines format has no place for such flag,
+// so its batch is flushed at once after batch marker file, which keeps batch position for recovery.
+func (s *EventStorage) WriteBatch(batch [][]byte) (offset int, err error) {
+	if s.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	if len(batch) == 0 {
+		return s.write.offset, nil
+	}
+
+	var batchSize int64
+
+	for _, data := range batch {
+		if err = s.codec.validate(data); err != nil {
+			return 0, err
+		}
+
+		batchSize += s.codec.size(data)
+	}
+
+	if s.write.fileEvents > 0 && s.write.fileSize+batchSize > s.write.fileMaxSize {
+		if err = s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	if s.codec.format == FormatLines {
+		// Batch is the only data of its flush, so marker describes it exactly.
+		if _, err = s.flush(); err != nil {
+			return 0, err
+		}
+
+		if err = s.markBatch(s.write.fileSize, s.write.fileSize+batchSize); err != nil {
+			return 0, err
+		}
+	}
+
+	offset = s.write.offset
+
+	for i, data := range batch {
+		// Events are validated above, so the batch is not written partially.
+		_, _ = s.append(data, i < len(batch)-1)
+	}
+
+	if s.codec.format == FormatLines {
+		if _, err = s.flush(); err != nil {
+			return offset, err
+		}
+	}
+
+	return offset, s.flushAppended()
+}
+
+// markBatch saves position of lines batch in the last events file before the batch is flushed.
+func (s *EventStorage) markBatch(start int64, end int64) error {
+	file, err := s.fsys.OpenFile(s.getFilePath(batchFileName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return errors.New("failed to create batch file: " + err.Error())
+	}
+
+	_, err = file.Write(encodeIndex(nil, []int64{int64(s.lastFileNumber()), start, end}, nil))
+
+	if err == nil && s.write.durability != DurabilityNone {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.New("failed to write batch file: " + err.Error())
+	}
+
+	if s.write.durability != DurabilityNone && !s.write.batchMarked {
+		// Marker file is created once after open, then it is rewritten in place.
+		if err = s.fsys.SyncDir(s.basePath); err != nil {
+			return errors.New("failed sync storage directory: " + err.Error())
+		}
+	}
+
+	s.write.batchMarked = true
+
+	return nil
+}
+
+// tornBatchStart returns position of lines batch, which was not flushed completely into events file
+// of size, or -1 if there is no such batch.
+func (s *EventStorage) tornBatchStart(file *eventsFile) int64 {
+	if s.codec.format != FormatLines {
+		return -1
+	}
+
+	raw, err := s.readFile(s.getFilePath(batchFileName))
+
+	if err != nil || len(raw) != indexEntrySize*3 {
+		return -1
+	}
+
+	number := int(binary.LittleEndian.Uint64(raw))
+	start := int64(binary.LittleEndian.Uint64(raw[indexEntrySize:]))
+	end := int64(binary.LittleEndian.Uint64(raw[indexEntrySize*2:]))
+
+	if number != file.number || file.size <= start || file.size >= end {
+		return -1
+	}
+
+	return start
+}
+
+// clearBatch removes batch marker after recovery, so later events of the file are never taken for batch.
+func (s *EventStorage) clearBatch() error {
+	if err := s.fsys.Remove(s.getFilePath(batchFileName)); err != nil && !os.IsNotExist(err) {
+		return errors.New("failed to remove batch file: " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package eventstorage
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func Test_eventStorage_WriteBatch(t *testing.T) {
+	storage, _ := New(t.TempDir(), WithFormat(FormatFramed))
+	t.Cleanup(storage.Shutdown)
+	storage.SetWriteFileMaxSize(30)
+	storage.SetAutoFlushCount(2)
+	_, _ = storage.Write([]byte("first"))
+
+	offset, err := storage.WriteBatch([][]byte{[]byte("one"), []byte("two"), []byte("three")})
+
+	if err != nil || offset != 1 {
+		t.Fatalf("WriteBatch expect offset 1, got %v, err: %v", offset, err)
+	}
+
+	if len(storage.files) != 2 || storage.files[1].count != 3 {
+		t.Errorf("WriteBatch expect batch in its own file, got %v files", len(storage.files))
+	}
+
+	_, _ = storage.Flush()
+	events, err := storage.Read(5, 0)
+
+	if err != nil || len(events) != 4 || events[1] != "one" || events[3] != "three" {
+		t.Errorf("WriteBatch read incorrect events %q, err: %v", events, err)
+	}
+}
+
+func Test_eventStorage_WriteBatchEmpty(t *testing.T) {
+	storage, _ := New(t.TempDir(), WithFormat(FormatFramed))
+	t.Cleanup(storage.Shutdown)
+	_, _ = storage.Write([]byte("first"))
+
+	if offset, err := storage.WriteBatch(nil); err != nil || offset != 1 {
+		t.Errorf("WriteBatchEmpty expect offset 1, got %v, err: %v", offset, err)
+	}
+}
+
+func Test_eventStorage_WriteBatchLines(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	_, _ = storage.Write([]byte("first"))
+
+	if _, err := storage.WriteBatch([][]byte{[]byte("one"), []byte("t\nwo")}); err != ErrLineBreakInEvent {
+		t.Errorf("WriteBatchLines expect %v, got %v", ErrLineBreakInEvent, err)
+	}
+
+	offset, err := storage.WriteBatch([][]byte{[]byte("one"), []byte("two")})
+
+	if err != nil || offset != 1 {
+		t.Fatalf("WriteBatchLines expect offset 1, got %v, err: %v", offset, err)
+	}
+
+	events, err := storage.Read(4, 0)
+
+	if err != nil || len(events) != 3 || events[1] != "one" || events[2] != "two" {
+		t.Errorf("WriteBatchLines read incorrect events %q, err: %v", events, err)
+	}
+}
+
+func Test_eventStorage_RecoveryDropsIncompleteLinesBatch(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	_, _ = storage.Write([]byte("first"))
+	_, _ = storage.WriteBatch([][]byte{[]byte("one"), []byte("two"), []byte("three")})
+	storage.Shutdown()
+
+	// Only the first lines of batch reached events file.
+	name := storage.getFilePath(storage.getFileName(1))
+	_ = os.Truncate(name, int64(len("first\none\ntwo\n")))
+
+	storage, err := New(path)
+
+	if err != nil {
+		t.Fatalf("RecoveryDropsIncompleteLinesBatch failed to open, err: %v", err)
+	}
+
+	t.Cleanup(storage.Shutdown)
+	expected := Recovery{File: 1, TruncatedBytes: int64(len("one\ntwo\n"))}
+
+	if storage.Recovery() != expected {
+		t.Errorf("RecoveryDropsIncompleteLinesBatch expect %+v, got %+v", expected, storage.Recovery())
+	}
+
+	if _, err = os.Stat(storage.getFilePath(batchFileName)); !os.IsNotExist(err) {
+		t.Errorf("RecoveryDropsIncompleteLinesBatch expect removed batch file, got %v", err)
+	}
+
+	if offset, _ := storage.Write([]byte("next")); offset != 1 {
+		t.Errorf("RecoveryDropsIncompleteLinesBatch expect next offset 1, got %v", offset)
+	}
+}
+
+func Test_eventStorage_RecoveryDropsIncompleteBatch(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path, WithFormat(FormatFramed))
+	_, _ = storage.WriteBatch([][]byte{[]byte("one"), []byte("two")})
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	validSize := storage.write.fileSize
+	torn := new(bytes.Buffer)
+	size := storage.codec.append(torn, []byte("three"), 0, true)
+	size += storage.codec.append(torn, []byte("four"), 0, true)
+	file, _ := os.OpenFile(storage.getFilePath(storage.getFileName(1)), os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = file.Write(torn.Bytes())
+	_ = file.Close()
+
+	storage, err := New(path)
+
+	if err != nil {
+		t.Fatalf("RecoveryDropsIncompleteBatch failed to open, err: %v", err)
+	}
+
+	t.Cleanup(storage.Shutdown)
+	expected := Recovery{File: 1, TruncatedBytes: size}
+
+	if storage.Recovery() != expected {
+		t.Errorf("RecoveryDropsIncompleteBatch expect %+v, got %+v", expected, storage.Recovery())
+	}
+
+	if storage.calculateWriteFileSize() != validSize {
+		t.Errorf("RecoveryDropsIncompleteBatch expect file size %v, got %v", validSize, storage.calculateWriteFileSize())
+	}
+
+	if offset, _ := storage.Write([]byte("next")); offset != 2 {
+		t.Errorf("RecoveryDropsIncompleteBatch expect next offset 2, got %v", offset)
+	}
+}
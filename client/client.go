@@ -0,0 +1,404 @@
+// Package client is Go client of events storage server, see package server.
+package client
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pankif/eventstorage"
+	"github.com/pankif/eventstorage/protocol"
+)
+
+const (
+	connBufSize     = 64 << 10
+	maxPendingCalls = 1024 // Count of sent requests, which may wait for responses.
+)
+
+var ErrClientClosed = errors.New("client closed")
+
+// Storage is method set shared by local storage and Client, so code can switch between them.
+type Storage interface {
+	Write(data []byte) (offset int, err error)
+	Read(count int, offset int) ([]string, error)
+	ReadTo(count int, offset int, events []string) (int, error)
+	Flush() (count int, err error)
+}
+
+var (
+	_ Storage = (*eventstorage.EventStorage)(nil)
+	_ Storage = (*Client)(nil)
+)
+
+// Client sends requests of many goroutines over one connection without waiting for previous responses.
+// Client is safe for concurrent use.
+type Client struct {
+	addr      string
+	conn      net.Conn
+	writer    *bufio.Writer
+	locker    sync.Mutex    // Requests sending lock.
+	pending   chan *call    // Sent requests waiting for responses, in order of sending.
+	err       error         // Connection error, all next calls fail with it.
+	errLocker sync.Mutex    // Connection error lock.
+	done      chan struct{} // Closed when responses receiving stops.
+}
+
+// call is request waiting for response.
+type call struct {
+	response []byte
+	err      error
+	done     chan struct{}
+}
+
+// Dial connects to server address.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		addr:    addr,
+		conn:    conn,
+		writer:  bufio.NewWriterSize(conn, connBufSize),
+		pending: make(chan *call, maxPendingCalls),
+		done:    make(chan struct{}),
+	}
+
+	go c.receive(bufio.NewReaderSize(conn, connBufSize))
+
+	return c, nil
+}
+
+// Write appends event and returns its offset.
+func (c *Client) Write(data []byte) (offset int, err error) {
+	response, err := c.call(append([]byte{protocol.CommandWrite}, data...))
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(response) != 8 {
+		return 0, protocol.ErrMalformedFrame
+	}
+
+	return int(binary.LittleEndian.Uint64(response)), nil
+}
+
+// Read reads up to count events starting from offset.
+func (c *Client) Read(count int, offset int) ([]string, error) {
+	events := make([]string, count)
+	saved, err := c.ReadTo(count, offset, events)
+
+	return events[:saved], err
+}
+
+// ReadTo reads up to count events starting from offset into events and returns count of read events.
+// Server limits count of events of one request, so it may read less events than there are.
+func (c *Client) ReadTo(count int, offset int, events []string) (int, error) {
+	request := []byte{protocol.CommandRead}
+	request = protocol.AppendInt64(request, int64(offset))
+	request = protocol.AppendUint32(request, uint32(count))
+	response, err := c.call(request)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(response) < 4 {
+		return 0, protocol.ErrMalformedFrame
+	}
+
+	received := int(binary.LittleEndian.Uint32(response))
+	response = response[4:]
+	saved := 0
+
+	for ; saved < received && saved < count; saved++ {
+		if len(response) < 4 || len(response)-4 < int(binary.LittleEndian.Uint32(response)) {
+			return saved, protocol.ErrMalformedFrame
+		}
+
+		size := int(binary.LittleEndian.Uint32(response))
+		events[saved] = string(response[4 : 4+size])
+		response = response[4+size:]
+	}
+
+	return saved, nil
+}
+
+// Flush flushes events written by all clients and returns count of flushed events.
+func (c *Client) Flush() (count int, err error) {
+	response, err := c.call([]byte{protocol.CommandFlush})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(response) != 8 {
+		return 0, protocol.ErrMalformedFrame
+	}
+
+	return int(binary.LittleEndian.Uint64(response)), nil
+}
+
+// ReplicaState returns snapshot of server registry and kept events files for replication.
+func (c *Client) ReplicaState() (eventstorage.ReplicaState, error) {
+	response, err := c.call([]byte{protocol.CommandState})
+
+	if err != nil {
+		return eventstorage.ReplicaState{}, err
+	}
+
+	return protocol.ParseReplicaState(response)
+}
+
+// ReadFileAt reads bytes of kept events file of server starting at offset, like io.ReaderAt.
+// Server limits size of one request, so it is read by several requests.
+func (c *Client) ReadFileAt(name string, p []byte, off int64) (int, error) {
+	read := 0
+
+	for read < len(p) {
+		request := []byte{protocol.CommandReadFile}
+		request = protocol.AppendInt64(request, off+int64(read))
+		request = protocol.AppendUint32(request, uint32(len(p)-read))
+		response, err := c.call(append(request, name...))
+
+		if err != nil {
+			return read, err
+		}
+
+		if len(response) == 0 {
+			return read, io.EOF
+		}
+
+		read += copy(p[read:], response)
+	}
+
+	return read, nil
+}
+
+// Close closes connection, waiting calls fail with ErrClientClosed.
+func (c *Client) Close() error {
+	c.setErr(ErrClientClosed)
+	err := c.conn.Close()
+	<-c.done
+
+	return err
+}
+
+// call sends request and waits for its response payload without status.
+func (c *Client) call(request []byte) ([]byte, error) {
+	cl := &call{done: make(chan struct{})}
+	c.locker.Lock()
+
+	if err := c.getErr(); err != nil {
+		c.locker.Unlock()
+		return nil, err
+	}
+
+	select {
+	case c.pending <- cl:
+	case <-c.done:
+		c.locker.Unlock()
+		return nil, c.getErr()
+	}
+
+	err := protocol.WriteFrame(c.writer, request)
+
+	if err == nil {
+		err = c.writer.Flush()
+	}
+
+	c.locker.Unlock()
+
+	if err != nil {
+		// Broken connection stops receiving, so the call is done with error.
+		c.setErr(err)
+		_ = c.conn.Close()
+	}
+
+	select {
+	case <-cl.done:
+	case <-c.done:
+		// Call may be sent after waiting calls were finished by receive.
+		select {
+		case <-cl.done:
+		default:
+			return nil, c.getErr()
+		}
+	}
+
+	if cl.err != nil {
+		return nil, cl.err
+	}
+
+	if err = protocol.ResponseError(cl.response); err != nil {
+		return nil, err
+	}
+
+	return cl.response[1:], nil
+}
+
+// receive reads responses and passes them to calls in order of sending.
+func (c *Client) receive(reader *bufio.Reader) {
+	defer close(c.done)
+
+	for {
+		response, err := protocol.ReadFrame(reader, nil)
+
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		select {
+		case cl := <-c.pending:
+			cl.response = response
+			close(cl.done)
+		default:
+			c.fail(protocol.ErrMalformedFrame)
+			return
+		}
+	}
+}
+
+// fail finishes waiting calls with connection error.
+func (c *Client) fail(err error) {
+	c.setErr(err)
+	err = c.getErr()
+	_ = c.conn.Close()
+
+	for {
+		select {
+		case cl := <-c.pending:
+			cl.err = err
+			close(cl.done)
+		default:
+			return
+		}
+	}
+}
+
+// setErr keeps the first connection error.
+func (c *Client) setErr(err error) {
+	c.errLocker.Lock()
+	defer c.errLocker.Unlock()
+
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *Client) getErr() error {
+	c.errLocker.Lock()
+	defer c.errLocker.Unlock()
+
+	return c.err
+}
+
+// Subscription delivers events of server storage starting from offset, it uses own connection.
+type Subscription struct {
+	conn      net.Conn
+	events    chan eventstorage.Event
+	err       error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Subscribe returns subscription delivering events starting from offset, buffer is the capacity of events channel.
+// Buffer must fit uint32 of protocol, otherwise eventstorage.ErrInvalidSubscribeBuffer is returned.
+func (c *Client) Subscribe(fromOffset int, buffer int) (*Subscription, error) {
+	if buffer < 0 || int64(buffer) > math.MaxUint32 {
+		return nil, eventstorage.ErrInvalidSubscribeBuffer
+	}
+
+	conn, err := net.Dial("tcp", c.addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request := []byte{protocol.CommandSubscribe}
+	request = protocol.AppendInt64(request, int64(fromOffset))
+	request = protocol.AppendUint32(request, uint32(buffer))
+
+	if err = protocol.WriteFrame(conn, request); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	sub := &Subscription{conn: conn, events: make(chan eventstorage.Event, buffer), done: make(chan struct{})}
+	go sub.receive(bufio.NewReaderSize(conn, connBufSize))
+
+	return sub, nil
+}
+
+// Events returns channel of events, it is closed when subscription ends.
+func (sub *Subscription) Events() <-chan eventstorage.Event {
+	return sub.events
+}
+
+// Err returns the reason of subscription end, it is valid after events channel closed.
+// Nil means that subscription was closed by Close.
+func (sub *Subscription) Err() error {
+	return sub.err
+}
+
+// Close closes subscription connection, events channel is closed after that.
+func (sub *Subscription) Close() error {
+	var err error
+
+	sub.closeOnce.Do(func() {
+		close(sub.done)
+		err = sub.conn.Close()
+	})
+
+	return err
+}
+
+func (sub *Subscription) receive(reader *bufio.Reader) {
+	defer close(sub.events)
+
+	for {
+		response, err := protocol.ReadFrame(reader, nil)
+
+		if err == nil {
+			err = protocol.ResponseError(response)
+		}
+
+		if err == nil && len(response) < protocol.SubscribeEventMinSize {
+			err = protocol.ErrMalformedFrame
+		}
+
+		if err != nil {
+			select {
+			case <-sub.done:
+			default:
+				sub.err = err
+			}
+
+			return
+		}
+
+		event := eventstorage.Event{
+			Offset: int(binary.LittleEndian.Uint64(response[1:])),
+			Data:   response[protocol.SubscribeEventMinSize:],
+		}
+
+		if nanos := int64(binary.LittleEndian.Uint64(response[9:])); nanos != 0 {
+			event.Time = time.Unix(0, nanos)
+		}
+
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pankif/eventstorage"
+	"github.com/pankif/eventstorage/server"
+)
+
+func startServer(t *testing.T) string {
+	storage, err := eventstorage.New(t.TempDir())
+
+	if err != nil {
+		t.Fatalf("failed to open storage, err: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("failed to listen, err: %v", err)
+	}
+
+	srv := server.New(storage)
+	go func() { _ = srv.Serve(listener) }()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+		storage.Shutdown()
+	})
+
+	return listener.Addr().String()
+}
+
+func Test_Client_WriteFlushRead(t *testing.T) {
+	c, err := Dial(startServer(t))
+
+	if err != nil {
+		t.Fatalf("Dial failed, err: %v", err)
+	}
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				if _, err := c.Write([]byte("event " + strconv.Itoa(i))); err != nil {
+					t.Errorf("Client Write failed, err: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if count, err := c.Flush(); err != nil || count != 800 {
+		t.Errorf("Client Flush expect 800 events, got %v, err: %v", count, err)
+	}
+
+	events, err := c.Read(1000, 790)
+
+	if err != nil || len(events) != 10 {
+		t.Errorf("Client Read expect 10 events, got %v, err: %v", len(events), err)
+	}
+
+	offset, _ := c.Write([]byte("last"))
+	_, _ = c.Flush()
+	buf := make([]string, 2)
+
+	if n, err := c.ReadTo(2, offset, buf); err != nil || n != 1 || buf[0] != "last" {
+		t.Errorf("Client ReadTo expect last event, got %q, err: %v", buf[:n], err)
+	}
+}
+
+func Test_Client_Subscribe(t *testing.T) {
+	c, _ := Dial(startServer(t))
+	t.Cleanup(func() { _ = c.Close() })
+
+	_, _ = c.Write([]byte("history"))
+	_, _ = c.Flush()
+
+	sub, err := c.Subscribe(0, 10)
+
+	if err != nil {
+		t.Fatalf("Client Subscribe failed, err: %v", err)
+	}
+
+	_, _ = c.Write([]byte("live"))
+	_, _ = c.Flush()
+
+	for i, expected := range []string{"history", "live"} {
+		select {
+		case event := <-sub.Events():
+			if event.Offset != i || string(event.Data) != expected {
+				t.Errorf("Client Subscribe expect %v at %v, got %q at %v", expected, i, event.Data, event.Offset)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Client Subscribe expect %v, got timeout", expected)
+		}
+	}
+
+	_ = sub.Close()
+
+	for range sub.Events() {
+	}
+
+	if sub.Err() != nil {
+		t.Errorf("Client Subscribe expect nil error after Close, got %v", sub.Err())
+	}
+}
+
+func Test_Client_SubscribeInvalidBuffer(t *testing.T) {
+	c, _ := Dial(startServer(t))
+	t.Cleanup(func() { _ = c.Close() })
+
+	if sub, err := c.Subscribe(0, -1); sub != nil || err != eventstorage.ErrInvalidSubscribeBuffer {
+		t.Errorf("Client Subscribe expect %v for negative buffer, got %v", eventstorage.ErrInvalidSubscribeBuffer, err)
+	}
+}
+
+func Test_Client_Closed(t *testing.T) {
+	c, _ := Dial(startServer(t))
+	_ = c.Close()
+
+	if _, err := c.Write([]byte("event")); err != ErrClientClosed {
+		t.Errorf("Client Write expect %v after Close, got %v", ErrClientClosed, err)
+	}
+}
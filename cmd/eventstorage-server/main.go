@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pankif/eventstorage"
+	tcpserver "github.com/pankif/eventstorage/server"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	addr := flag.String("addr", ":8080", "HTTP listen address")
+	tcpAddr := flag.String("tcp-addr", "", "Binary protocol listen address, empty - disable")
+	path := flag.String("path", "./", "Base path of events storage")
+	format := flag.String("format", "lines", "Events format of a new storage: lines or framed")
+	fileMaxSize := flag.Int64("file-max-size", 100*eventstorage.MB, "Size of events file for create a new file, see SetWriteFileMaxSize")
+	autoFlushCount := flag.Int("auto-flush-count", 0, "Auto flush after N count of events insert, 0 - disable, see SetAutoFlushCount")
+	autoFlushTime := flag.Duration("auto-flush-time", time.Second, "Auto flush period, 0 - disable, see SetAutoFlushTime")
+	flag.Parse()
+
+	if err := run(*addr, *tcpAddr, *path, *format, *fileMaxSize, *autoFlushCount, *autoFlushTime); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(addr string, tcpAddr string, path string, format string, fileMaxSize int64, autoFlushCount int, autoFlushTime time.Duration) error {
+	options := []eventstorage.Option{}
+
+	switch format {
+	case eventstorage.FormatLines.String():
+	case eventstorage.FormatFramed.String():
+		options = append(options, eventstorage.WithFormat(eventstorage.FormatFramed))
+	default:
+		return eventstorage.ErrUnknownFormat
+	}
+
+	storage, err := eventstorage.New(path, options...)
+
+	if err != nil {
+		return err
+	}
+
+	defer storage.Shutdown()
+	storage.SetWriteFileMaxSize(fileMaxSize)
+	storage.SetAutoFlushCount(autoFlushCount)
+
+	if autoFlushTime > 0 {
+		if err = storage.SetAutoFlushTime(autoFlushTime); err != nil {
+			return err
+		}
+	}
+
+	server := newHTTPServer(addr, storage)
+	stopped := make(chan error, 2)
+
+	go func() {
+		stopped <- server.ListenAndServe()
+	}()
+
+	var tcpServer *tcpserver.Server
+
+	if tcpAddr != "" {
+		tcpServer = tcpserver.New(storage)
+
+		go func() {
+			stopped <- tcpServer.ListenAndServe(tcpAddr)
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err = <-stopped:
+	case <-signals:
+	}
+
+	if stopErr := stop(server, tcpServer, storage, shutdownTimeout); err == nil {
+		err = stopErr
+	}
+
+	return err
+}
+
+// newHTTPServer returns HTTP server of storage, its tail requests stop waiting on shutdown.
+func newHTTPServer(addr string, storage *eventstorage.EventStorage) *http.Server {
+	handler := newServer(storage)
+	server := &http.Server{Addr: addr, Handler: handler}
+	server.RegisterOnShutdown(handler.shutdown)
+
+	return server
+}
+
+// stop shuts down servers and flushes storage even if shutdown fails, it returns the first error.
+func stop(server *http.Server, tcpServer *tcpserver.Server, storage *eventstorage.EventStorage, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+
+	if tcpServer != nil {
+		if closeErr := tcpServer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if _, flushErr := storage.Flush(); err == nil {
+		err = flushErr
+	}
+
+	return err
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pankif/eventstorage"
+)
+
+const (
+	defaultReadCount   = 100
+	maxReadCount       = 10000
+	defaultTailTimeout = 30 * time.Second
+	maxTailTimeout     = 5 * time.Minute
+	maxEventSize       = 16 << 20
+)
+
+// server serves events storage over HTTP:
+//
+//	POST /events                                 append request body as event, responds {"offset":N}
+//	GET  /events?offset=N&count=M                read up to M events from offset N
+//	GET  /events/tail?offset=N&count=M&timeout=D wait up to D for events from offset N
+//	POST /flush                                  flush written events, responds {"flushed":N}
+//	GET  /stats                                  server and storage counters
+//	GET  /metrics                                storage counters in Prometheus text format
+//
+// Read responses are {"events":[...],"next":N}, where next is offset to continue reading from.
+// Events are base64 encoded, so binary events of framed format are returned as is.
+type server struct {
+	storage      *eventstorage.EventStorage
+	mux          *http.ServeMux
+	started      time.Time
+	stats        stats
+	shuttingDown chan struct{} // Closed on shutdown, so tail requests stop waiting.
+	shutdownOnce sync.Once
+}
+
+// stats is counters of server requests.
+type stats struct {
+	Written    int64  `json:"written"`     // Count of appended events.
+	Read       int64  `json:"read"`        // Count of returned events.
+	Flushed    int64  `json:"flushed"`     // Count of events flushed by flush requests.
+	NextOffset int64  `json:"next_offset"` // Offset of the next appended event, -1 before the first append.
+	Uptime     string `json:"uptime"`
+
+	Storage eventstorage.Stats `json:"storage"`
+}
+
+type readResponse struct {
+	Events [][]byte `json:"events"`
+	Next   int      `json:"next"`
+}
+
+func newServer(storage *eventstorage.EventStorage) *server {
+	s := &server{storage: storage, mux: http.NewServeMux(), started: time.Now(), shuttingDown: make(chan struct{})}
+	s.stats.NextOffset = -1
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/events/tail", s.handleTail)
+	s.mux.HandleFunc("/flush", s.handleFlush)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.Handle("/metrics", storage.PrometheusHandler())
+
+	return s
+}
+
+// shutdown ends waiting of tail requests, they respond with events received so far.
+func (s *server) shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.shuttingDown)
+	})
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handleAppend(w, r)
+	case http.MethodGet:
+		s.handleRead(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *server) handleAppend(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEventSize))
+
+	if err != nil {
+		http.Error(w, "failed to read event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset, err := s.storage.WriteContext(r.Context(), data)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	atomic.AddInt64(&s.stats.Written, 1)
+	atomic.StoreInt64(&s.stats.NextOffset, int64(offset+1))
+	writeJSON(w, map[string]int{"offset": offset})
+}
+
+func (s *server) handleRead(w http.ResponseWriter, r *http.Request) {
+	offset, count, err := readParams(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.storage.ReadContext(r.Context(), count, offset)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	raw := make([][]byte, len(events))
+
+	for i, event := range events {
+		raw[i] = []byte(event)
+	}
+
+	s.writeEvents(w, raw, offset)
+}
+
+// handleTail responds as soon as there are events from offset, or with empty list after timeout.
+func (s *server) handleTail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	offset, count, err := readParams(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeout, err := durationParam(r, "timeout", defaultTailTimeout)
+
+	if err != nil || timeout > maxTailTimeout {
+		http.Error(w, "timeout must be duration up to "+maxTailTimeout.String(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.storage.Subscribe(offset, count, eventstorage.SlowWait)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	defer sub.Close()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	events := make([][]byte, 0, count)
+
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			writeError(w, sub.Err())
+			return
+		}
+
+		events = append(events, event.Data)
+	case <-timer.C:
+	case <-s.shuttingDown:
+	case <-r.Context().Done():
+		return
+	}
+
+	// Take events, which are already available, without waiting.
+	for len(events) > 0 && len(events) < count {
+		select {
+		case event, ok := <-sub.Events():
+			if ok {
+				events = append(events, event.Data)
+				continue
+			}
+		default:
+		}
+
+		break
+	}
+
+	s.writeEvents(w, events, offset)
+}
+
+func (s *server) handleFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	count, err := s.storage.FlushContext(r.Context())
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	atomic.AddInt64(&s.stats.Flushed, int64(count))
+	writeJSON(w, map[string]int{"flushed": count})
+}
+
+func (s *server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, stats{
+		Written:    atomic.LoadInt64(&s.stats.Written),
+		Read:       atomic.LoadInt64(&s.stats.Read),
+		Flushed:    atomic.LoadInt64(&s.stats.Flushed),
+		NextOffset: atomic.LoadInt64(&s.stats.NextOffset),
+		Uptime:     time.Since(s.started).Round(time.Second).String(),
+		Storage:    s.storage.Stats(),
+	})
+}
+
+func (s *server) writeEvents(w http.ResponseWriter, events [][]byte, offset int) {
+	atomic.AddInt64(&s.stats.Read, int64(len(events)))
+	writeJSON(w, readResponse{Events: events, Next: offset + len(events)})
+}
+
+// readParams returns offset and count query parameters of read requests.
+func readParams(r *http.Request) (offset int, count int, err error) {
+	if offset, err = intParam(r, "offset", 0); err != nil || offset < 0 {
+		return 0, 0, errors.New("offset must be not negative integer")
+	}
+
+	if count, err = intParam(r, "count", defaultReadCount); err != nil || count <= 0 || count > maxReadCount {
+		return 0, 0, errors.New("count must be integer from 1 to " + strconv.Itoa(maxReadCount))
+	}
+
+	return offset, count, nil
+}
+
+func intParam(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+func durationParam(r *http.Request, name string, defaultValue time.Duration) (time.Duration, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// writeError responds with status matching storage error.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, eventstorage.ErrLineBreakInEvent):
+		status = http.StatusBadRequest
+	case errors.Is(err, eventstorage.ErrOffsetRemoved):
+		status = http.StatusGone
+	case errors.Is(err, eventstorage.ErrStorageShutdown):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusServiceUnavailable
+	}
+
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pankif/eventstorage"
+)
+
+func newTestServer(t *testing.T, options ...eventstorage.Option) *httptest.Server {
+	storage, err := eventstorage.New(t.TempDir(), options...)
+
+	if err != nil {
+		t.Fatalf("failed to open storage, err: %v", err)
+	}
+
+	ts := httptest.NewServer(newServer(storage))
+	t.Cleanup(func() {
+		ts.Close()
+		storage.Shutdown()
+	})
+
+	return ts
+}
+
+func decode(t *testing.T, resp *http.Response, err error, value interface{}) {
+	if err != nil {
+		t.Fatalf("request failed, err: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expect status 200, got %v", resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(value); err != nil {
+		t.Fatalf("failed to decode response, err: %v", err)
+	}
+}
+
+func Test_server_AppendFlushRead(t *testing.T) {
+	ts := newTestServer(t)
+	var appended map[string]int
+
+	for _, event := range []string{"first", "second"} {
+		resp, err := http.Post(ts.URL+"/events", "text/plain", strings.NewReader(event))
+		decode(t, resp, err, &appended)
+	}
+
+	if appended["offset"] != 1 {
+		t.Errorf("append expect offset 1, got %v", appended["offset"])
+	}
+
+	var flushed map[string]int
+	resp, err := http.Post(ts.URL+"/flush", "", nil)
+	decode(t, resp, err, &flushed)
+
+	if flushed["flushed"] != 2 {
+		t.Errorf("flush expect 2 events, got %v", flushed["flushed"])
+	}
+
+	var read readResponse
+	resp, err = http.Get(ts.URL + "/events?offset=1&count=10")
+	decode(t, resp, err, &read)
+
+	if len(read.Events) != 1 || string(read.Events[0]) != "second" || read.Next != 2 {
+		t.Errorf("read expect second event and next 2, got %+v", read)
+	}
+
+	var s stats
+	resp, err = http.Get(ts.URL + "/stats")
+	decode(t, resp, err, &s)
+
+	if s.Written != 2 || s.Read != 1 || s.Flushed != 2 || s.NextOffset != 2 {
+		t.Errorf("stats expect written 2, read 1, flushed 2, next offset 2, got %+v", s)
+	}
+
+	if s.Storage.Writes != 2 || s.Storage.FlushedOffset != 2 {
+		t.Errorf("stats expect storage writes 2 and flushed offset 2, got %+v", s.Storage)
+	}
+}
+
+func Test_server_ReadBinary(t *testing.T) {
+	ts := newTestServer(t, eventstorage.WithFormat(eventstorage.FormatFramed))
+	event := []byte{0xff, 0x00, '\n', 0xfe}
+
+	var appended map[string]int
+	resp, err := http.Post(ts.URL+"/events", "application/octet-stream", bytes.NewReader(event))
+	decode(t, resp, err, &appended)
+
+	resp, err = http.Post(ts.URL+"/flush", "", nil)
+	decode(t, resp, err, &appended)
+
+	var read readResponse
+	resp, err = http.Get(ts.URL + "/events?offset=0")
+	decode(t, resp, err, &read)
+
+	if len(read.Events) != 1 || !bytes.Equal(read.Events[0], event) {
+		t.Errorf("read expect binary event %v, got %+v", event, read)
+	}
+}
+
+func Test_server_Tail(t *testing.T) {
+	ts := newTestServer(t)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = http.Post(ts.URL+"/events", "text/plain", strings.NewReader("live"))
+		_, _ = http.Post(ts.URL+"/flush", "", nil)
+	}()
+
+	var read readResponse
+	resp, err := http.Get(ts.URL + "/events/tail?offset=0&timeout=5s")
+	decode(t, resp, err, &read)
+
+	if len(read.Events) != 1 || string(read.Events[0]) != "live" || read.Next != 1 {
+		t.Errorf("tail expect live event, got %+v", read)
+	}
+
+	resp, err = http.Get(ts.URL + "/events/tail?offset=1&timeout=10ms")
+	decode(t, resp, err, &read)
+
+	if len(read.Events) != 0 || read.Next != 1 {
+		t.Errorf("tail expect no events after timeout, got %+v", read)
+	}
+}
+
+func Test_server_BadRequest(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, url := range []string{"/events?offset=-1", "/events?count=0", "/events/tail?timeout=1h"} {
+		resp, err := http.Get(ts.URL + url)
+
+		if err != nil {
+			t.Fatalf("request failed, err: %v", err)
+		}
+
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%v expect status 400, got %v", url, resp.StatusCode)
+		}
+	}
+}
+
+func Test_stop(t *testing.T) {
+	storage, _ := eventstorage.New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	_, _ = storage.Write([]byte("buffered"))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("failed to listen, err: %v", err)
+	}
+
+	server := newHTTPServer("", storage)
+	go func() { _ = server.Serve(listener) }()
+
+	tailed := make(chan readResponse, 1)
+
+	go func() {
+		var read readResponse
+
+		if resp, err := http.Get("http://" + listener.Addr().String() + "/events/tail?offset=1&timeout=5m"); err == nil {
+			_ = json.NewDecoder(resp.Body).Decode(&read)
+			_ = resp.Body.Close()
+		}
+
+		tailed <- read
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	started := time.Now()
+
+	if err = stop(server, nil, storage, 5*time.Second); err != nil || time.Since(started) > time.Second {
+		t.Errorf("stop expect tail request to end without waiting, got err %v after %v", err, time.Since(started))
+	}
+
+	if read := <-tailed; read.Events == nil || len(read.Events) != 0 || read.Next != 1 {
+		t.Errorf("tail expect no events on shutdown, got %+v", read)
+	}
+
+	if events, _ := storage.Read(1, 0); len(events) != 1 || events[0] != "buffered" {
+		t.Errorf("stop expect buffered event flushed, got %q", events)
+	}
+}
@@ -0,0 +1,310 @@
+// Command eventstorage inspects and maintains events storage directory.
+//
+//	eventstorage [-path dir] cat [-offset N] [-count M] [-raw]
+//	eventstorage [-path dir] tail [-n N] [-f] [-raw]
+//	eventstorage [-path dir] count
+//	eventstorage [-path dir] stats
+//	eventstorage [-path dir] verify
+//	eventstorage [-path dir] rotate
+//	eventstorage [-path dir] follow -leader host:port [-interval D]
+//
+// Commands but rotate and follow open storage read-only, so they may be used while storage is written.
+// Events are printed one per line, so framed events with line breaks are ambiguous. With -raw every event
+// is prefixed by its length as uint32 little-endian instead, like framed format without checksums.
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pankif/eventstorage"
+	"github.com/pankif/eventstorage/client"
+	"github.com/pankif/eventstorage/replication"
+)
+
+const usage = `Usage: eventstorage [-path dir] <command> [flags]
+
+Commands:
+  cat     print events, flags: -offset N -count M -raw
+  tail    print the last events, flags: -n N -f -raw
+  count   print count of kept events
+  stats   print events files from registry with sizes and events counts
+  verify  read all events and check them, with checksums enabled
+  rotate  flush written events and start a new events file
+  follow  replicate storage of leader server into path until interrupted, flags: -leader host:port -interval D
+
+Events are printed one per line, -raw prints every event prefixed by its length as uint32 little-endian,
+so events with line breaks of framed format may be split back.
+`
+
+func main() {
+	flags := flag.NewFlagSet("eventstorage", flag.ExitOnError)
+	path := flags.String("path", "./", "Base path of events storage")
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	_ = flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(os.Stdout, *path, flags.Arg(0), flags.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(out io.Writer, path string, command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	offset := flags.Int("offset", -1, "Offset of the first event, the first kept event by default")
+	count := flags.Int("count", 0, "Count of events, 0 - all events")
+	last := flags.Int("n", 10, "Count of the last events")
+	follow := flags.Bool("f", false, "Print new events as they are flushed")
+	raw := flags.Bool("raw", false, "Print every event prefixed by its length instead of line break after it")
+	leader := flags.String("leader", "", "Leader server address")
+	interval := flags.Duration("interval", 100*time.Millisecond, "Leader polling interval")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if command == "rotate" {
+		return rotate(out, path)
+	}
+
+	if command == "follow" {
+		return followLeader(out, path, *leader, *interval)
+	}
+
+	storage, err := eventstorage.OpenReadOnly(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer storage.Shutdown()
+
+	switch command {
+	case "cat":
+		return cat(out, storage, *offset, *count, *raw)
+	case "tail":
+		return tail(out, storage, *last, *follow, *raw)
+	case "count":
+		files := storage.Files()
+		_, err = fmt.Fprintln(out, files[len(files)-1].FirstOffset+files[len(files)-1].Count-files[0].FirstOffset)
+		return err
+	case "stats":
+		return stats(out, storage)
+	case "verify":
+		return verify(out, storage)
+	}
+
+	return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+}
+
+func cat(out io.Writer, storage *eventstorage.EventStorage, offset int, count int, raw bool) error {
+	if offset < 0 {
+		offset = storage.Files()[0].FirstOffset
+	}
+
+	cursor := storage.Cursor(offset)
+	defer cursor.Close()
+
+	for printed := 0; count == 0 || printed < count; printed++ {
+		event, err := cursor.Next()
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = printEvent(out, event, raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tail prints the last events, with follow it waits for new events until interrupted.
+func tail(out io.Writer, storage *eventstorage.EventStorage, last int, follow bool, raw bool) error {
+	events, err := storage.ReadLast(last)
+
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err = printEvent(out, []byte(event), raw); err != nil {
+			return err
+		}
+	}
+
+	if !follow {
+		return nil
+	}
+
+	files := storage.Files()
+	cursor := storage.Cursor(files[len(files)-1].FirstOffset + files[len(files)-1].Count)
+	defer cursor.Close()
+
+	for {
+		event, err := cursor.Next()
+
+		if err == io.EOF {
+			time.Sleep(200 * time.Millisecond)
+
+			if err = storage.Refresh(); err != nil {
+				return err
+			}
+
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if err = printEvent(out, event, raw); err != nil {
+			return err
+		}
+	}
+}
+
+func stats(out io.Writer, storage *eventstorage.EventStorage) error {
+	var size int64
+	var count int
+
+	for _, file := range storage.Files() {
+		compressed := ""
+
+		if file.Compressed {
+			compressed = " compressed"
+		}
+
+		if _, err := fmt.Fprintf(out, "%s\tfirst offset %d\tevents %d\tsize %d%s\n", file.Name, file.FirstOffset, file.Count, file.Size, compressed); err != nil {
+			return err
+		}
+
+		size += file.Size
+		count += file.Count
+	}
+
+	_, err := fmt.Fprintf(out, "total\tfiles %d\tevents %d\tsize %d\n", len(storage.Files()), count, size)
+
+	return err
+}
+
+// verify reads all kept events, broken events are reported by CorruptionError.
+func verify(out io.Writer, storage *eventstorage.EventStorage) error {
+	cursor := storage.Cursor(storage.Files()[0].FirstOffset)
+	defer cursor.Close()
+
+	count := 0
+
+	for {
+		if _, err := cursor.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		count++
+	}
+
+	_, err := fmt.Fprintf(out, "ok, %d events\n", count)
+
+	return err
+}
+
+func rotate(out io.Writer, path string) error {
+	storage, err := eventstorage.New(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer storage.Shutdown()
+
+	if err = storage.Rotate(); err != nil {
+		return err
+	}
+
+	files := storage.Files()
+	_, err = fmt.Fprintln(out, "current file", files[len(files)-1].Name)
+
+	return err
+}
+
+// followLeader replicates leader storage and prints replication lag every second until interrupted.
+func followLeader(out io.Writer, path string, leader string, interval time.Duration) error {
+	if leader == "" {
+		return errors.New("leader address is required")
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	c, err := client.Dial(leader)
+
+	if err != nil {
+		return err
+	}
+
+	defer c.Close()
+
+	follower := replication.NewFollower(c, path)
+	follower.SetPollInterval(interval)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	stopped := make(chan error, 1)
+
+	go func() {
+		stopped <- follower.Run(ctx)
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err = <-stopped:
+			if err == context.Canceled {
+				return nil
+			}
+
+			return err
+		case <-ticker.C:
+			lag := follower.Lag()
+			_, _ = fmt.Fprintf(out, "lag %d events %d bytes, synced %s\n", lag.Events, lag.Bytes, lag.Synced.Format(time.RFC3339))
+		}
+	}
+}
+
+// printEvent prints event with line break after it, raw event is prefixed by its length instead.
+func printEvent(out io.Writer, event []byte, raw bool) error {
+	if !raw {
+		_, err := out.Write(append(event, '\n'))
+		return err
+	}
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(event)))
+
+	if _, err := out.Write(length[:]); err != nil {
+		return err
+	}
+
+	_, err := out.Write(event)
+
+	return err
+}
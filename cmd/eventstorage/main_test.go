@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pankif/eventstorage"
+)
+
+func fillStorage(t *testing.T) string {
+	path := t.TempDir()
+	storage, err := eventstorage.New(path, eventstorage.WithFormat(eventstorage.FormatFramed), eventstorage.WithChecksums())
+
+	if err != nil {
+		t.Fatalf("failed to open storage, err: %v", err)
+	}
+
+	storage.SetWriteFileMaxSize(140)
+
+	for i := 0; i < 20; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	return path
+}
+
+func runCommand(t *testing.T, path string, args ...string) string {
+	out := new(bytes.Buffer)
+
+	if err := run(out, path, args[0], args[1:]); err != nil {
+		t.Fatalf("%v failed, err: %v", args, err)
+	}
+
+	return out.String()
+}
+
+func Test_run(t *testing.T) {
+	path := fillStorage(t)
+
+	if out := runCommand(t, path, "cat", "-offset", "18"); out != "event 18\nevent 19\n" {
+		t.Errorf("cat expect the last 2 events, got %q", out)
+	}
+
+	if out := runCommand(t, path, "cat", "-count", "1"); out != "event 0\n" {
+		t.Errorf("cat expect the first event, got %q", out)
+	}
+
+	if out := runCommand(t, path, "cat", "-count", "1", "-raw"); out != "\x07\x00\x00\x00event 0" {
+		t.Errorf("cat expect the first event prefixed by its length, got %q", out)
+	}
+
+	if out := runCommand(t, path, "tail", "-n", "1", "-raw"); out != "\x08\x00\x00\x00event 19" {
+		t.Errorf("tail expect the last event prefixed by its length, got %q", out)
+	}
+
+	if out := runCommand(t, path, "tail", "-n", "1"); out != "event 19\n" {
+		t.Errorf("tail expect the last event, got %q", out)
+	}
+
+	if out := runCommand(t, path, "tail", "-n", "-1"); out != "" {
+		t.Errorf("tail expect no events for negative count, got %q", out)
+	}
+
+	if out := runCommand(t, path, "count"); out != "20\n" {
+		t.Errorf("count expect 20, got %q", out)
+	}
+
+	if out := runCommand(t, path, "stats"); !strings.HasPrefix(out, "events.1\tfirst offset 0") || !strings.Contains(out, "total\tfiles 3\tevents 20") {
+		t.Errorf("stats expect files and total, got %q", out)
+	}
+
+	if out := runCommand(t, path, "verify"); out != "ok, 20 events\n" {
+		t.Errorf("verify expect ok, got %q", out)
+	}
+
+	if out := runCommand(t, path, "rotate"); out != "current file events.4\n" {
+		t.Errorf("rotate expect the next file, got %q", out)
+	}
+}
+
+func Test_runVerifyBroken(t *testing.T) {
+	path := fillStorage(t)
+	filePath := path + string(os.PathSeparator) + "events.1"
+	data, _ := os.ReadFile(filePath)
+	data[len(data)-1]++
+	_ = os.WriteFile(filePath, data, 0644)
+
+	if err := run(new(bytes.Buffer), path, "verify", nil); err == nil {
+		t.Errorf("verify expect corruption error")
+	}
+}
+
+func Test_runUnknownCommand(t *testing.T) {
+	if err := run(new(bytes.Buffer), fillStorage(t), "unknown", nil); err == nil {
+		t.Errorf("expect error of unknown command")
+	}
+}
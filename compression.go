@@ -0,0 +1,346 @@
+package eventstorage
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Compression of sealed events files.
+type Compression int
+
+const (
+	CompressionNone Compression = iota // Sealed events files are kept as is.
+	CompressionGzip                    // Sealed events files are compressed by gzip in background.
+)
+
+// SetCompression sets compression of sealed events files. Enabled compression starts compression
+// of existing sealed files, every rotated file is compressed after rotation.
+// Compressed files are read transparently.
+func (s *EventStorage) SetCompression(compression Compression) {
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	s.write.compression = compression
+
+	if compression == CompressionNone {
+		return
+	}
+
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	for _, file := range s.files[:len(s.files)-1] {
+		if !file.compressed {
+			s.compressInBackground(file.number)
+		}
+	}
+}
+
+// CompressionError returns the last error of background compression.
+func (s *EventStorage) CompressionError() error {
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	return s.write.compressErr
+}
+
+// compressInBackground starts compression of sealed events file, it must be called under write locker.
+// File being compressed is skipped, so one file is never compressed twice at once.
+func (s *EventStorage) compressInBackground(number int) {
+	if s.write.compression == CompressionNone || s.readOnly || s.write.shuttingDown || s.write.compressingFiles[number] {
+		return
+	}
+
+	if s.write.compressingFiles == nil {
+		s.write.compressingFiles = make(map[int]bool)
+	}
+
+	s.write.compressingFiles[number] = true
+	s.write.compressing.Add(1)
+
+	go func() {
+		defer s.write.compressing.Done()
+		err := s.compressFile(number)
+
+		s.write.locker.Lock()
+		defer s.write.locker.Unlock()
+
+		delete(s.write.compressingFiles, number)
+
+		if err != nil {
+			s.write.compressErr = err
+		}
+	}()
+}
+
+// compressFile writes compressed copy of sealed events file, replaces the file in registry and removes it.
+func (s *EventStorage) compressFile(number int) error {
+	plainName := s.getFileName(number)
+	compressedPath := s.getFilePath(plainName + compressedFileSuffix)
+	tmpPath := compressedPath + tmpFileSuffix
+	blocks, err := s.writeCompressed(plainName, tmpPath, s.keptPositions(number))
+
+	if err == nil {
+		// Blocks file is written before compressed file, so compressed file never misses it.
+		if err = s.writeFile(s.getBlocksPath(number), encodeIndex(nil, blocks, nil)); err != nil {
+			err = errors.New("failed to write compressed blocks file: " + err.Error())
+		}
+	}
+
+	if err != nil {
+		_ = s.fsys.Remove(tmpPath)
+
+		if s.keptFile(number) == nil {
+			// File was removed by retention during compression.
+			_ = s.fsys.Remove(s.getBlocksPath(number))
+			return nil
+		}
+
+		return err
+	}
+
+	if err = s.fsys.Rename(tmpPath, compressedPath); err != nil {
+		return errors.New("failed to replace compressed file: " + err.Error())
+	}
+
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	s.read.locker.Lock()
+	defer s.read.locker.Unlock()
+
+	file := s.keptFile(number)
+
+	if file == nil {
+		// File was removed by retention during compression.
+		_ = s.fsys.Remove(s.getBlocksPath(number))
+		return s.fsys.Remove(compressedPath)
+	}
+
+	compressed, err := s.fsys.Open(compressedPath)
+
+	if err != nil {
+		return errors.New("failed to open compressed file: " + err.Error())
+	}
+
+	s.filesLocker.Lock()
+	plain := file.reader
+	file.reader = compressed
+	file.compressed = true
+	file.blocks = blocks
+	s.filesLocker.Unlock()
+
+	if err = s.rewriteRegistry(); err != nil {
+		return err
+	}
+
+	_ = plain.Close()
+
+	if err = s.fsys.Remove(s.getFilePath(plainName)); err != nil {
+		return errors.New("failed to remove compressed events file: " + err.Error())
+	}
+
+	return nil
+}
+
+// writeCompressed writes every index block of events file as separate gzip member and returns compressed
+// positions of members. Members make one gzip stream, so reading may start from any member.
+func (s *EventStorage) writeCompressed(plainName string, path string, positions []int64) ([]int64, error) {
+	plain, err := s.fsys.Open(s.getFilePath(plainName))
+
+	if err != nil {
+		return nil, errors.New("failed to open events file for compression: " + err.Error())
+	}
+
+	defer plain.Close()
+
+	file, err := s.fsys.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return nil, errors.New("failed to create compressed file: " + err.Error())
+	}
+
+	if len(positions) == 0 {
+		positions = []int64{0}
+	}
+
+	counter := &countingWriter{writer: file}
+	writer := gzip.NewWriter(counter)
+	blocks := make([]int64, 0, len(positions))
+
+	for i := 0; i < len(positions) && err == nil; i++ {
+		end := int64(math.MaxInt64)
+
+		if i+1 < len(positions) {
+			end = positions[i+1]
+		}
+
+		blocks = append(blocks, counter.written)
+		writer.Reset(counter)
+
+		if _, err = io.Copy(writer, io.NewSectionReader(plain, positions[i], end-positions[i])); err == nil {
+			err = writer.Close()
+		}
+	}
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, errors.New("failed to write compressed file: " + err.Error())
+	}
+
+	return blocks, nil
+}
+
+// countingWriter counts bytes written into writer.
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+
+	return n, err
+}
+
+// keptPositions returns index of kept events file, nil if it is removed.
+func (s *EventStorage) keptPositions(number int) []int64 {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	for _, file := range s.files {
+		if file.number == number {
+			return file.positions
+		}
+	}
+
+	return nil
+}
+
+// loadBlocks returns compressed positions of index blocks of compressed file,
+// nil for file compressed as one gzip member or with broken blocks file.
+func (s *EventStorage) loadBlocks(file *eventsFile) []int64 {
+	raw, err := s.readFile(s.getBlocksPath(file.number))
+
+	if err != nil {
+		return nil
+	}
+
+	if blocks, _ := decodeIndex(raw, math.MaxInt64, false); len(blocks) == len(file.positions) && len(raw) == len(blocks)*indexEntrySize {
+		return blocks
+	}
+
+	return nil
+}
+
+func (s *EventStorage) getBlocksPath(number int) string {
+	return s.getFilePath(s.getFileName(number) + compressedFileSuffix + blocksFileSuffix)
+}
+
+// keptFile returns events file by number, nil if it is removed.
+func (s *EventStorage) keptFile(number int) *eventsFile {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	for _, file := range s.files {
+		if file.number == number {
+			return file
+		}
+	}
+
+	return nil
+}
+
+// open returns events file data starting from pos. Compressed file is decompressed from gzip member of index block
+// of pos, file compressed as one member is decompressed from its beginning.
+func (r *recordReader) open(file eventsFile, pos int64) (io.Reader, error) {
+	if !file.compressed {
+		return io.NewSectionReader(file.reader, pos, file.size-pos), nil
+	}
+
+	var err error
+	var blockPos, compressedPos int64
+
+	if len(file.blocks) > 0 && len(file.blocks) == len(file.positions) {
+		if block := sort.Search(len(file.positions), func(i int) bool { return file.positions[i] > pos }) - 1; block >= 0 {
+			blockPos, compressedPos = file.positions[block], file.blocks[block]
+		}
+	}
+
+	src := io.NewSectionReader(file.reader, compressedPos, math.MaxInt64-compressedPos)
+
+	if r.gzip == nil {
+		r.gzip, err = gzip.NewReader(src)
+	} else {
+		err = r.gzip.Reset(src)
+	}
+
+	if err != nil {
+		return nil, &CorruptionError{File: file.number, Position: blockPos, Err: err}
+	}
+
+	if _, err = io.CopyN(io.Discard, r.gzip, pos-blockPos); err != nil {
+		return nil, &CorruptionError{File: file.number, Position: pos, Err: err}
+	}
+
+	return r.gzip, nil
+}
+
+// registryFileLine returns events file line of registry,
+// compressed file line contains size of not compressed data and count of events.
+func (s *EventStorage) registryFileLine(file *eventsFile) string {
+	if file.compressed {
+		return fmt.Sprintf("%s %d %d", s.getEventsFileName(file), file.size, file.count)
+	}
+
+	return s.getEventsFileName(file)
+}
+
+func parseRegistryFile(line string) (*eventsFile, error) {
+	file := &eventsFile{}
+	fields := strings.Fields(line)
+
+	if len(fields) == 0 {
+		return nil, errors.New("empty events file name")
+	}
+
+	if _, err := fmt.Sscanf(fields[0], eventsFileNameTemplate, &file.number); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(fields[0], compressedFileSuffix) {
+		return file, nil
+	}
+
+	if len(fields) != 3 {
+		return nil, errors.New("compressed events file without size and count")
+	}
+
+	var err error
+	file.compressed = true
+
+	if file.size, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+		return nil, err
+	}
+
+	if file.count, err = strconv.Atoi(fields[2]); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
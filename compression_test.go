@@ -0,0 +1,174 @@
+package eventstorage
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func Test_eventStorage_Compression(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	storage.SetWriteFileMaxSize(20 * KB)
+	storage.SetCompression(CompressionGzip)
+
+	const iterCount = indexInterval * 4
+
+	for i := 0; i < iterCount; i++ {
+		_, _ = storage.Write([]byte("repetitive event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+	storage.write.compressing.Wait()
+
+	if err := storage.CompressionError(); err != nil {
+		t.Errorf("Compression failed, err: %v", err)
+		return
+	}
+
+	for _, file := range storage.files[:len(storage.files)-1] {
+		if !file.compressed {
+			t.Errorf("Compression expect sealed file %v compressed", file.number)
+		}
+
+		if _, err := os.Stat(storage.getFilePath(storage.getFileName(file.number))); !os.IsNotExist(err) {
+			t.Errorf("Compression expect plain file %v removed", file.number)
+		}
+	}
+
+	storage.Shutdown()
+	_ = os.Remove(storage.getIndexPath(2))
+
+	storage, err := New(path)
+
+	if err != nil {
+		t.Errorf("Compression failed to open, err: %v", err)
+		return
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if !storage.files[0].compressed || len(storage.files) < 3 {
+		t.Errorf("Compression expect compressed files in registry")
+		return
+	}
+
+	for _, offset := range []int{0, indexInterval + 3, iterCount - 1} {
+		if events, err := storage.Read(1, offset); err != nil || len(events) != 1 || events[0] != "repetitive event "+strconv.Itoa(offset) {
+			t.Errorf("Compression read at %v got %q, err: %v", offset, events, err)
+		}
+	}
+
+	cursor := storage.Cursor(0)
+	defer cursor.Close()
+
+	for i := 0; i < iterCount; i++ {
+		if event, err := cursor.Next(); err != nil || string(event) != "repetitive event "+strconv.Itoa(i) {
+			t.Errorf("Compression cursor expect event %v, got %q, err: %v", i, event, err)
+			return
+		}
+	}
+}
+
+func Test_eventStorage_CompressionRepeated(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	storage.SetWriteFileMaxSize(KB)
+
+	for i := 0; i < 200; i++ {
+		_, _ = storage.Write([]byte("repetitive event " + strconv.Itoa(i)))
+	}
+
+	for i := 0; i < 3; i++ {
+		storage.SetCompression(CompressionGzip)
+	}
+
+	_ = storage.Rotate()
+	storage.SetCompression(CompressionGzip)
+	storage.write.compressing.Wait()
+
+	if err := storage.CompressionError(); err != nil {
+		t.Errorf("CompressionRepeated expect no error, got %v", err)
+	}
+
+	for _, file := range storage.Files()[:len(storage.Files())-1] {
+		if !file.Compressed {
+			t.Errorf("CompressionRepeated expect sealed file %v compressed", file.Name)
+		}
+	}
+
+	if events, err := storage.Read(200, 0); err != nil || len(events) != 200 {
+		t.Errorf("CompressionRepeated expect all events, got %v, err: %v", len(events), err)
+	}
+}
+
+func Test_eventStorage_CompressionBlocks(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	storage.SetCompression(CompressionGzip)
+
+	const iterCount = indexInterval * 3
+
+	for i := 0; i < iterCount; i++ {
+		_, _ = storage.Write([]byte("repetitive event " + strconv.Itoa(i)))
+	}
+
+	_ = storage.Rotate()
+	storage.write.compressing.Wait()
+
+	if file := storage.files[0]; !file.compressed || len(file.blocks) != 3 || file.blocks[0] != 0 {
+		t.Fatalf("CompressionBlocks expect gzip member of every index block, got %v", file.blocks)
+	}
+
+	storage.Shutdown()
+
+	// Broken first member does not break reading of the last block, it is decompressed from its own member.
+	gzPath := storage.getFilePath(storage.getFileName(1) + compressedFileSuffix)
+	data, _ := os.ReadFile(gzPath)
+
+	for i := 20; i < 40; i++ {
+		data[i] ^= 0xff
+	}
+
+	_ = os.WriteFile(gzPath, data, 0644)
+	storage, _ = New(path)
+	t.Cleanup(storage.Shutdown)
+
+	if events, err := storage.Read(1, iterCount-1); err != nil || len(events) != 1 || events[0] != "repetitive event "+strconv.Itoa(iterCount-1) {
+		t.Errorf("CompressionBlocks expect event of the last block, got %q, err: %v", events, err)
+	}
+
+	if _, err := storage.Read(1, 0); err == nil {
+		t.Errorf("CompressionBlocks expect error of broken first block")
+	}
+}
+
+func Test_eventStorage_CompressionAfterShutdown(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	storage.SetCompression(CompressionGzip)
+	storage.Shutdown()
+
+	storage.write.locker.Lock()
+	storage.compressInBackground(1)
+	storage.write.locker.Unlock()
+
+	if len(storage.write.compressingFiles) != 0 {
+		t.Errorf("CompressionAfterShutdown expect no compression started, got %v", storage.write.compressingFiles)
+	}
+}
+
+func Test_parseRegistryFile(t *testing.T) {
+	file, err := parseRegistryFile("events.3.gz 1024 10")
+
+	if err != nil || file.number != 3 || !file.compressed || file.size != 1024 || file.count != 10 {
+		t.Errorf("parseRegistryFile incorrect compressed file %+v, err: %v", file, err)
+	}
+
+	if file, err = parseRegistryFile("events.4"); err != nil || file.number != 4 || file.compressed {
+		t.Errorf("parseRegistryFile incorrect file %+v, err: %v", file, err)
+	}
+
+	if _, err = parseRegistryFile("events.5.gz"); err == nil {
+		t.Errorf("parseRegistryFile expect error for compressed file without size")
+	}
+}
@@ -0,0 +1,140 @@
+package eventstorage
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Commit saves offset of the next event to read by named consumer.
+// Offsets are saved atomically into consumers file beside the registry.
+func (s *EventStorage) Commit(name string, offset int) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return ErrInvalidConsumerName
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	s.consumersLocker.Lock()
+	defer s.consumersLocker.Unlock()
+
+	if s.consumers == nil {
+		s.consumers = make(map[string]int)
+	}
+
+	previous, existed := s.consumers[name]
+	s.consumers[name] = offset
+
+	if err := s.saveConsumers(); err != nil {
+		if existed {
+			s.consumers[name] = previous
+		} else {
+			delete(s.consumers, name)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Committed returns offset committed by named consumer, consumer without commits starts from 0.
+func (s *EventStorage) Committed(name string) int {
+	s.consumersLocker.Lock()
+	defer s.consumersLocker.Unlock()
+
+	return s.consumers[name]
+}
+
+// ReadConsumer reads up to count events starting from offset committed by named consumer.
+func (s *EventStorage) ReadConsumer(name string, count int) ([]string, error) {
+	return s.Read(count, s.Committed(name))
+}
+
+// ConsumerCursor returns cursor starting from offset committed by named consumer.
+func (s *EventStorage) ConsumerCursor(name string) *Cursor {
+	return s.Cursor(s.Committed(name))
+}
+
+func (s *EventStorage) loadConsumers() error {
+	file, err := s.fsys.Open(s.getFilePath(consumersFileName))
+
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.New("Failed to open consumers file: " + err.Error())
+	}
+
+	defer file.Close()
+
+	s.consumers = make(map[string]int)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		name, offset, _ := strings.Cut(scanner.Text(), " ")
+
+		if s.consumers[name], err = strconv.Atoi(offset); err != nil {
+			return errors.New("Failed to read consumers file: " + err.Error())
+		}
+	}
+
+	return scanner.Err()
+}
+
+// consumersData returns content of consumers file, it must be called under consumers locker.
+func (s *EventStorage) consumersData() string {
+	names := make([]string, 0, len(s.consumers))
+
+	for name := range s.consumers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var data strings.Builder
+
+	for _, name := range names {
+		data.WriteString(name + " " + strconv.Itoa(s.consumers[name]) + "\n")
+	}
+
+	return data.String()
+}
+
+// saveConsumers replaces consumers file by temporary one, so it is never partially written.
+func (s *EventStorage) saveConsumers() error {
+	data := s.consumersData()
+	tmpPath := s.getFilePath(consumersFileName + tmpFileSuffix)
+	file, err := s.fsys.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return errors.New("failed to create consumers file: " + err.Error())
+	}
+
+	if _, err = io.WriteString(file, data); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.New("failed to write consumers file: " + err.Error())
+	}
+
+	if err = s.fsys.Rename(tmpPath, s.getFilePath(consumersFileName)); err != nil {
+		return errors.New("failed to replace consumers file: " + err.Error())
+	}
+
+	if err = s.fsys.SyncDir(s.basePath); err != nil {
+		return errors.New("failed sync storage directory: " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package eventstorage
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func Test_eventStorage_CommitAfterRestart(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+
+	for i := 0; i < 10; i++ {
+		_, _ = storage.Write([]byte(strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+
+	if err := storage.Commit("billing", 4); err != nil {
+		t.Errorf("Commit failed, err: %v", err)
+		return
+	}
+
+	_ = storage.Commit("audit", 7)
+	storage.Shutdown()
+
+	storage, err := New(path)
+
+	if err != nil {
+		t.Errorf("CommitAfterRestart failed to open, err: %v", err)
+		return
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if storage.Committed("billing") != 4 || storage.Committed("audit") != 7 || storage.Committed("unknown") != 0 {
+		t.Errorf("CommitAfterRestart incorrect committed offsets %v", storage.consumers)
+	}
+
+	if events, err := storage.ReadConsumer("billing", 2); err != nil || len(events) != 2 || events[0] != "4" {
+		t.Errorf("ReadConsumer expect events from committed offset, got %q, err: %v", events, err)
+	}
+
+	cursor := storage.ConsumerCursor("audit")
+	defer cursor.Close()
+
+	if event, err := cursor.Next(); err != nil || string(event) != "7" {
+		t.Errorf("ConsumerCursor expect event from committed offset, got %q, err: %v", event, err)
+	}
+
+	if _, err = os.Stat(storage.getFilePath(consumersFileName + tmpFileSuffix)); !os.IsNotExist(err) {
+		t.Errorf("CommitAfterRestart expect temporary consumers file replaced")
+	}
+}
+
+func Test_eventStorage_CommitInvalidName(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	for _, name := range []string{"", "with space", "line\nbreak"} {
+		if err := storage.Commit(name, 1); err != ErrInvalidConsumerName {
+			t.Errorf("CommitInvalidName expect error for %q, got %v", name, err)
+		}
+	}
+}
+
+func Test_eventStorage_CommitFailedKeepsPrevious(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	_ = storage.Commit("billing", 1)
+	storage.basePath = string([]byte{0})
+
+	if err := storage.Commit("billing", 2); err == nil || storage.Committed("billing") != 1 {
+		t.Errorf("CommitFailedKeepsPrevious expect error and previous offset, got %v", storage.Committed("billing"))
+	}
+}
@@ -0,0 +1,92 @@
+package eventstorage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	minLockPause = 50 * time.Microsecond // The first pause between lock attempts of LockContext.
+	maxLockPause = time.Millisecond      // Max pause between lock attempts of LockContext.
+)
+
+// contextLocker is mutex, which waiting may be canceled by context. Zero value is unlocked.
+// Lock and Unlock are plain sync.Mutex, only LockContext pays for cancellation.
+type contextLocker struct {
+	sync.Mutex
+}
+
+// LockContext waits for lock until context is done, then it returns ctx.Err().
+// Contended lock is retried after growing pause.
+func (l *contextLocker) LockContext(ctx context.Context) error {
+	pause := minLockPause
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if l.TryLock() {
+			return nil
+		}
+
+		timer := time.NewTimer(pause)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		if pause *= 2; pause > maxLockPause {
+			pause = maxLockPause
+		}
+	}
+}
+
+// WriteContext is Write, which stops waiting for write lock when context is done.
+func (s *EventStorage) WriteContext(ctx context.Context, data []byte) (offset int, err error) {
+	if s.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	if err = s.write.locker.LockContext(ctx); err != nil {
+		return 0, err
+	}
+
+	defer s.write.locker.Unlock()
+
+	if offset, err = s.append(data, false); err != nil {
+		return 0, err
+	}
+
+	return offset, s.flushAppended()
+}
+
+// FlushContext is Flush, which stops waiting for write lock when context is done.
+func (s *EventStorage) FlushContext(ctx context.Context) (count int, err error) {
+	if err = s.write.locker.LockContext(ctx); err != nil {
+		return 0, err
+	}
+
+	defer s.write.locker.Unlock()
+
+	return s.flush()
+}
+
+// ReadContext is Read, which stops waiting for read lock and reading events data when context is done.
+// Events read before cancellation are returned with ctx.Err().
+func (s *EventStorage) ReadContext(ctx context.Context, count int, offset int) ([]string, error) {
+	if err := s.read.locker.LockContext(ctx); err != nil {
+		return nil, err
+	}
+
+	defer s.read.locker.Unlock()
+
+	events := make([]string, count)
+	saved, err := s.readTo(ctx, count, offset, events)
+
+	return events[:saved], err
+}
@@ -0,0 +1,75 @@
+package eventstorage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countdownContext is done after the given count of Err calls.
+type countdownContext struct {
+	context.Context
+	calls int
+}
+
+func (c *countdownContext) Err() error {
+	if c.calls--; c.calls < 0 {
+		return context.Canceled
+	}
+
+	return nil
+}
+
+func Test_eventStorage_ContextWaitingForLock(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	storage.write.locker.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := storage.WriteContext(ctx, []byte("event")); err != context.DeadlineExceeded {
+		t.Errorf("ContextWaitingForLock expect WriteContext %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if _, err := storage.FlushContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("ContextWaitingForLock expect FlushContext %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	storage.write.locker.Unlock()
+	storage.read.locker.Lock()
+
+	if _, err := storage.ReadContext(ctx, 1, 0); err != context.DeadlineExceeded {
+		t.Errorf("ContextWaitingForLock expect ReadContext %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	storage.read.locker.Unlock()
+
+	if offset, err := storage.WriteContext(context.Background(), []byte("event")); err != nil || offset != 0 {
+		t.Errorf("ContextWaitingForLock expect WriteContext offset 0 after unlock, got %v, err: %v", offset, err)
+	}
+}
+
+func Test_eventStorage_ReadContextCanceledBetweenBufferReads(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	event := []byte(strings.Repeat("e", int(KB)))
+
+	for i := 0; i < 100; i++ {
+		_, _ = storage.Write(event)
+	}
+
+	_, _ = storage.Flush()
+
+	// The first call is made by LockContext, the second one by the first buffer read.
+	events, err := storage.ReadContext(&countdownContext{Context: context.Background(), calls: 2}, 100, 0)
+
+	if err != context.Canceled || len(events) == 0 || len(events) >= 100 {
+		t.Errorf("ReadContextCanceledBetweenBufferReads expect part of events with %v, got %v events, err: %v", context.Canceled, len(events), err)
+	}
+
+	if events, err = storage.ReadContext(context.Background(), 100, 0); err != nil || len(events) != 100 {
+		t.Errorf("ReadContextCanceledBetweenBufferReads expect all events after cancel, got %v events, err: %v", len(events), err)
+	}
+}
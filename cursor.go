@@ -0,0 +1,139 @@
+package eventstorage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Cursor reads events one by one starting from offset, independently of other cursors and ReadTo.
+// Cursor is not safe for concurrent use.
+type Cursor struct {
+	storage *EventStorage
+	offset  int           // Offset of the next event.
+	file    eventsFile    // Current events file, its reader is cursor own file handle.
+	reader  *recordReader // For read events of current file.
+	closed  bool
+}
+
+// Cursor returns cursor, which starts from event with offset.
+func (s *EventStorage) Cursor(offset int) *Cursor {
+	return &Cursor{
+		storage: s,
+		offset:  offset,
+		reader:  newRecordReader(make([]byte, readBufLimit), s.codec),
+	}
+}
+
+// Next returns the next event, io.EOF means there are no more flushed events yet
+// and Next may be called again later. Returned data is owned by caller.
+func (c *Cursor) Next() ([]byte, error) {
+	if c.closed {
+		return nil, ErrCursorClosed
+	}
+
+	if c.file.reader == nil || c.offset >= c.file.firstOffset+c.file.count {
+		if err := c.nextFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := c.reader.next()
+
+	if err != nil {
+		return nil, c.reader.committedErr(err)
+	}
+
+	c.offset++
+
+	return append([]byte(nil), record...), nil
+}
+
+// Offset returns offset of the event, which will be returned by the next call of Next.
+func (c *Cursor) Offset() int {
+	return c.offset
+}
+
+// Time returns write time of the event returned by the last call of Next, with timestamps enabled.
+func (c *Cursor) Time() time.Time {
+	if !c.reader.timestamps || c.reader.time == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, c.reader.time)
+}
+
+// Close releases cursor file handle.
+func (c *Cursor) Close() error {
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+
+	if c.file.reader != nil {
+		return c.file.reader.Close()
+	}
+
+	return nil
+}
+
+// nextFile prepares reader for events file with cursor offset, it keeps position in the same file.
+func (c *Cursor) nextFile() error {
+	file, err := c.storage.findFile(c.offset)
+
+	if err != nil {
+		return err
+	}
+
+	if c.file.reader != nil && file.number == c.file.number {
+		file.reader = c.file.reader
+		file.compressed = c.file.compressed
+		c.file = file
+		c.reader.reset(file.number, io.NewSectionReader(file.reader, c.reader.pos, file.size-c.reader.pos), c.reader.pos, file.size)
+
+		return nil
+	}
+
+	if c.file.reader != nil {
+		_ = c.file.reader.Close()
+		c.file.reader = nil
+	}
+
+	if file, err = c.storage.openFileByOffset(c.offset); err != nil {
+		return err
+	}
+
+	c.file = file
+
+	return c.reader.committedErr(c.reader.seek(file, c.offset-file.firstOffset))
+}
+
+// openFileByOffset returns copy of events file, which contains event with offset, with own file handle.
+func (s *EventStorage) openFileByOffset(offset int) (eventsFile, error) {
+	file, err := s.findFile(offset)
+
+	if err != nil {
+		return file, err
+	}
+
+	handle, err := s.fsys.Open(s.getFilePath(s.getEventsFileName(&file)))
+
+	if os.IsNotExist(err) && !file.compressed {
+		// File may be compressed after it was found.
+		if file, err = s.findFile(offset); err != nil {
+			return file, err
+		}
+
+		handle, err = s.fsys.Open(s.getFilePath(s.getEventsFileName(&file)))
+	}
+
+	if err != nil {
+		return file, errors.New("failed to open events file: " + err.Error())
+	}
+
+	file.reader = handle
+
+	return file, nil
+}
@@ -0,0 +1,95 @@
+package eventstorage
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func Test_Cursor_Next(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	storage.SetWriteFileMaxSize(5 * KB)
+	t.Cleanup(storage.Shutdown)
+
+	const iterCount = indexInterval * 2
+
+	for i := 0; i < iterCount; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+
+	cursor := storage.Cursor(10)
+	t.Cleanup(func() { _ = cursor.Close() })
+
+	for i := 10; i < iterCount; i++ {
+		event, err := cursor.Next()
+
+		if err != nil || string(event) != "event "+strconv.Itoa(i) {
+			t.Errorf("Cursor Next expect event %v, got %q, err: %v", i, event, err)
+			return
+		}
+	}
+
+	if _, err := cursor.Next(); err != io.EOF {
+		t.Errorf("Cursor Next expect EOF, got %v", err)
+		return
+	}
+
+	_, _ = storage.Write([]byte("new event"))
+	_, _ = storage.Flush()
+
+	if event, err := cursor.Next(); err != nil || string(event) != "new event" {
+		t.Errorf("Cursor Next expect new event after EOF, got %q, err: %v", event, err)
+	}
+
+	if cursor.Offset() != iterCount+1 {
+		t.Errorf("Cursor Offset expect %v, got %v", iterCount+1, cursor.Offset())
+	}
+}
+
+func Test_Cursor_Concurrent(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	for i := 0; i < 100; i++ {
+		_, _ = storage.Write([]byte(strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+
+	wg := sync.WaitGroup{}
+
+	for c := 0; c < 4; c++ {
+		wg.Add(1)
+
+		go func(offset int) {
+			defer wg.Done()
+
+			cursor := storage.Cursor(offset)
+			defer cursor.Close()
+
+			for i := offset; i < 100; i++ {
+				if event, err := cursor.Next(); err != nil || string(event) != strconv.Itoa(i) {
+					t.Errorf("Cursor Concurrent expect event %v, got %q, err: %v", i, event, err)
+					return
+				}
+			}
+		}(c * 10)
+	}
+
+	wg.Wait()
+}
+
+func Test_Cursor_Closed(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	cursor := storage.Cursor(0)
+	_ = cursor.Close()
+
+	if _, err := cursor.Next(); err != ErrCursorClosed {
+		t.Errorf("Cursor Closed expect error, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package eventstorage
+
+import (
+	"errors"
+	"time"
+)
+
+// Durability defines when flushed events are synced to disk.
+type Durability int
+
+const (
+	DurabilityNone     Durability = iota // Flushed events are left in OS page cache.
+	DurabilityFlush                      // Events are synced on every flush.
+	DurabilityWrite                      // Every written event is flushed and synced.
+	DurabilityInterval                   // Flushed events are synced at most once per sync interval.
+)
+
+// SetDurability sets when flushed events are synced to disk. Interval is used by DurabilityInterval only.
+// With any durability except DurabilityNone registry and storage directory are synced on events file rotation.
+func (s *EventStorage) SetDurability(durability Durability, interval time.Duration) error {
+	if durability == DurabilityInterval && interval <= 0 {
+		return ErrSyncIntervalTooLow
+	}
+
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	if durability == DurabilityInterval && s.write.syncInterval != 0 {
+		return ErrSyncIntervalAlreadySet
+	}
+
+	s.write.durability = durability
+
+	if durability != DurabilityInterval {
+		return nil
+	}
+
+	s.write.syncInterval = interval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.turnedOff:
+				return
+			case <-ticker.C:
+				_ = s.syncDirty()
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *EventStorage) GetDurability() Durability {
+	return s.write.durability
+}
+
+// syncFlushed syncs flushed events according to durability.
+func (s *EventStorage) syncFlushed() error {
+	switch s.write.durability {
+	case DurabilityFlush, DurabilityWrite:
+		if err := s.write.file.Sync(); err != nil {
+			return errors.New("sync failed: " + err.Error())
+		}
+	case DurabilityInterval:
+		s.write.dirty = true
+	}
+
+	return nil
+}
+
+func (s *EventStorage) syncDirty() error {
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	if !s.write.dirty || s.write.durability != DurabilityInterval {
+		return nil
+	}
+
+	if err := s.write.file.Sync(); err != nil {
+		return errors.New("sync failed: " + err.Error())
+	}
+
+	s.write.dirty = false
+
+	return nil
+}
+
+// syncRotated syncs sealed events file before close.
+func (s *EventStorage) syncRotated() error {
+	if s.write.durability == DurabilityNone {
+		return nil
+	}
+
+	if err := s.write.file.Sync(); err != nil {
+		return errors.New("failed sync old events file: " + err.Error())
+	}
+
+	s.write.dirty = false
+
+	return nil
+}
+
+// syncCreated syncs registry and storage directory, so a new events file is not lost.
+func (s *EventStorage) syncCreated() error {
+	if s.write.durability == DurabilityNone {
+		return nil
+	}
+
+	if err := s.filesRegistry.Sync(); err != nil {
+		return errors.New("failed sync registry: " + err.Error())
+	}
+
+	if err := s.fsys.SyncDir(s.basePath); err != nil {
+		return errors.New("failed sync storage directory: " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package eventstorage
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_eventStorage_SetDurabilityWrongInterval(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	if err := storage.SetDurability(DurabilityInterval, 0); err != ErrSyncIntervalTooLow {
+		t.Errorf("SetDurabilityWrongInterval expect error, got %v", err)
+	}
+
+	_ = storage.SetDurability(DurabilityInterval, time.Second)
+
+	if err := storage.SetDurability(DurabilityInterval, time.Second); err != ErrSyncIntervalAlreadySet {
+		t.Errorf("SetDurabilityWrongInterval expect already set error, got %v", err)
+	}
+
+	if err := storage.SetDurability(DurabilityFlush, 0); err != nil || storage.GetDurability() != DurabilityFlush {
+		t.Errorf("SetDurabilityWrongInterval expect durability changed, err: %v", err)
+	}
+}
+
+func Test_eventStorage_DurabilityWrite(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	storage.SetWriteFileMaxSize(10)
+	_ = storage.SetDurability(DurabilityWrite, 0)
+	t.Cleanup(storage.Shutdown)
+
+	_, _ = storage.Write([]byte("abc"))
+
+	if storage.calculateWriteFileSize() != 4 {
+		t.Errorf("DurabilityWrite expect event flushed on write")
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := storage.Write([]byte("abc")); err != nil {
+			t.Errorf("DurabilityWrite failed with rotation, err: %v", err)
+			return
+		}
+	}
+
+	if events, err := storage.Read(10, 0); err != nil || len(events) != 6 {
+		t.Errorf("DurabilityWrite expect 6 events, got %v, err: %v", len(events), err)
+	}
+}
+
+func Test_eventStorage_DurabilityInterval(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	_ = storage.SetDurability(DurabilityInterval, time.Millisecond)
+	t.Cleanup(storage.Shutdown)
+
+	_, _ = storage.Write([]byte("abc"))
+	_, _ = storage.Flush()
+	time.Sleep(50 * time.Millisecond)
+
+	storage.write.locker.Lock()
+	dirty := storage.write.dirty
+	storage.write.locker.Unlock()
+
+	if dirty {
+		t.Errorf("DurabilityInterval expect flushed events synced")
+	}
+}
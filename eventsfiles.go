@@ -4,46 +4,69 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
-func (s *EventStorage) openEventsFile(number int, appendRegistry bool) (*os.File, error) {
+func (s *EventStorage) openEventsFile(number int, appendRegistry bool) (File, error) {
 	fileName := s.getFileName(number)
 	filePath := s.getFilePath(fileName)
 
 	if appendRegistry {
-		if _, err := s.filesRegistry.WriteString(fileName + "\n"); err != nil {
+		if s.filesRegistry == nil {
+			return nil, errors.New("cant append events file without registry")
+		}
+
+		if _, err := io.WriteString(s.filesRegistry, fileName+"\n"); err != nil {
 			return nil, errors.New("failed to append in registry file: " + err.Error())
 		}
 	}
 
-	writeFile, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	writeFile, err := s.fsys.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if _, exists := s.read.readableFiles[number]; !exists {
-		readFile, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if s.lastFileNumber() < number {
+		readFile, err := s.fsys.Open(filePath)
 
 		if err != nil {
 			_ = writeFile.Close()
 			return nil, err
 		}
 
-		s.read.readableFiles[number] = readFile
+		s.appendFile(&eventsFile{number: number, reader: readFile})
 	}
 
 	return writeFile, nil
 }
 
 func (s *EventStorage) rotateEventsFile() error {
+	if s.write.file == nil {
+		return errors.New("cant rotate without events file")
+	}
+
+	if err := s.syncRotated(); err != nil {
+		return err
+	}
+
 	if err := s.write.file.Close(); err != nil {
 		return errors.New("failed close old events file: " + err.Error())
 	}
 
+	if err := s.write.indexFile.Close(); err != nil {
+		return errors.New("failed close old index file: " + err.Error())
+	}
+
 	s.write.file = nil
-	file, err := s.openEventsFile(s.filesCount()+1, true)
+	s.write.indexFile = nil
+	sealed := s.lastFileNumber()
+	number := sealed + 1
+	file, err := s.openEventsFile(number, true)
 
 	if err != nil {
 		return errors.New("rotate failed, open events file err: " + err.Error())
@@ -51,17 +74,74 @@ func (s *EventStorage) rotateEventsFile() error {
 
 	s.write.file = file
 	s.write.fileSize = 0
+	s.write.fileEvents = 0
+
+	if s.write.indexFile, err = s.openIndexFile(number); err != nil {
+		return errors.New("rotate failed, open index file err: " + err.Error())
+	}
+
+	if err = s.syncCreated(); err != nil {
+		return err
+	}
+
+	s.compressInBackground(sealed)
+	atomic.AddUint64(&s.counters.rotations, 1)
 
 	return nil
 }
 
+// Rotate flushes written events and starts a new events file, current file without events is kept.
+func (s *EventStorage) Rotate() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	if s.write.fileEvents == 0 {
+		return nil
+	}
+
+	return s.rotate()
+}
+
+// FileInfo describes kept events file.
+type FileInfo struct {
+	Name        string // File name in base path.
+	FirstOffset int    // Offset of the first file event.
+	Count       int    // Count of flushed events.
+	Size        int64  // Size of flushed events data, not compressed.
+	Compressed  bool
+}
+
+// Files returns kept events files in registry order, the last one is current file to write.
+func (s *EventStorage) Files() []FileInfo {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	files := make([]FileInfo, 0, len(s.files))
+
+	for _, file := range s.files {
+		files = append(files, FileInfo{
+			Name:        s.getEventsFileName(file),
+			FirstOffset: file.firstOffset,
+			Count:       file.count,
+			Size:        file.size,
+			Compressed:  file.compressed,
+		})
+	}
+
+	return files
+}
+
 func (s *EventStorage) initEventsFile() error {
 	if s.filesRegistry == nil {
 		return errors.New("cant init events file without registry")
 	}
 
 	needAppendRegistry := false
-	number := s.filesCount()
+	number := s.lastFileNumber()
 
 	if number == 0 {
 		number++
@@ -76,12 +156,16 @@ func (s *EventStorage) initEventsFile() error {
 		return errors.New("Failed to init events file: " + err.Error())
 	}
 
+	if s.write.indexFile, err = s.openIndexFile(number); err != nil {
+		return errors.New("Failed to init index file: " + err.Error())
+	}
+
 	return nil
 }
 
 func (s *EventStorage) initFilesRegistry() error {
 	filePath := s.getFilePath(registryFileName)
-	registry, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	registry, err := s.fsys.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
 
 	if err == nil {
 		s.filesRegistry = registry
@@ -89,24 +173,110 @@ func (s *EventStorage) initFilesRegistry() error {
 		return errors.New("Failed to init files registry: " + err.Error())
 	}
 
-	scanner := bufio.NewScanner(s.filesRegistry)
+	content, err := readRegistry(s.filesRegistry)
 
-	for scanner.Scan() {
-		path := s.getFilePath(scanner.Text())
-		file, err := os.OpenFile(path, os.O_RDONLY, 0644)
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
+	s.baseOffset = content.baseOffset
+
+	for _, file := range content.files {
+		if file.reader, err = s.fsys.Open(s.getFilePath(s.getEventsFileName(file))); err != nil {
 			return errors.New("Failed to open events file to read: " + err.Error())
 		}
 
-		s.read.readableFiles[s.filesCount()+1] = file
+		s.appendFile(file)
+	}
+
+	if content.linesCount > 0 {
+		s.codec = content.settings
+		return nil
+	}
+
+	if s.codec.format.String() == "" {
+		return ErrUnknownFormat
+	}
+
+	if s.codec.timestamps && s.codec.format != FormatFramed {
+		return ErrTimestampsRequireFramed
+	}
+
+	if _, err = io.WriteString(s.filesRegistry, s.codec.settings()); err != nil {
+		return errors.New("Failed to write registry settings: " + err.Error())
 	}
 
 	return nil
 }
 
-func (s *EventStorage) filesCount() int {
-	return len(s.read.readableFiles)
+// registryContent is storage settings, base offset and events files read from registry.
+type registryContent struct {
+	settings   codec // Registries without settings were created with lines format without checksums.
+	baseOffset int
+	files      []*eventsFile
+	linesCount int
+}
+
+// readRegistry reads registry content, events files are not opened.
+func readRegistry(registry io.Reader) (content registryContent, err error) {
+	scanner := bufio.NewScanner(registry)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		content.linesCount++
+
+		if line != "" && line[0] == registryHeader {
+			key, value, _ := strings.Cut(line[1:], "=")
+
+			if key == "offset" {
+				content.baseOffset, err = strconv.Atoi(value)
+			} else {
+				err = content.settings.parseSetting(key, value)
+			}
+
+			if err != nil {
+				return content, errors.New("Failed to read registry settings: " + err.Error())
+			}
+
+			continue
+		}
+
+		file, err := parseRegistryFile(line)
+
+		if err != nil {
+			return content, errors.New("Failed to read registry events file: " + err.Error())
+		}
+
+		content.files = append(content.files, file)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return content, errors.New("Failed to read files registry: " + err.Error())
+	}
+
+	return content, nil
+}
+
+func (s *EventStorage) appendFile(file *eventsFile) {
+	s.filesLocker.Lock()
+	defer s.filesLocker.Unlock()
+
+	if last := len(s.files) - 1; last >= 0 {
+		file.firstOffset = s.files[last].firstOffset + s.files[last].count
+	}
+
+	s.files = append(s.files, file)
+}
+
+func (s *EventStorage) lastFileNumber() int {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	if len(s.files) == 0 {
+		return 0
+	}
+
+	return s.files[len(s.files)-1].number
 }
 
 func (s *EventStorage) calculateWriteFileSize() int64 {
@@ -122,11 +292,26 @@ func (s *EventStorage) getFileName(number int) string {
 	return fmt.Sprintf(eventsFileNameTemplate, number)
 }
 
+// getEventsFileName returns name of events file on disk, which depends on its compression.
+func (s *EventStorage) getEventsFileName(file *eventsFile) string {
+	if file.compressed {
+		return s.getFileName(file.number) + compressedFileSuffix
+	}
+
+	return s.getFileName(file.number)
+}
+
 func (s *EventStorage) getFilePath(fileName string) string {
 	return s.basePath + string(os.PathSeparator) + fileName
 }
 
 func (s *EventStorage) Shutdown() {
+	// Rotation after the flag starts no compression, so Wait covers every running one.
+	s.write.locker.Lock()
+	s.write.shuttingDown = true
+	s.write.locker.Unlock()
+
+	s.write.compressing.Wait()
 	s.write.locker.Lock()
 	s.read.locker.Lock()
 
@@ -135,15 +320,22 @@ func (s *EventStorage) Shutdown() {
 		s.read.locker.Unlock()
 	}()
 
-	go func() {
-		s.turnedOff <- true
-	}()
+	if s.turnedOff != nil {
+		select {
+		case <-s.turnedOff:
+		default:
+			close(s.turnedOff)
+		}
+	}
 
-	_ = s.write.file.Close()
-	_ = s.filesRegistry.Close()
+	// Read-only storage and storage failed on open have no write files.
+	for _, file := range []io.Closer{s.write.file, s.write.indexFile, s.filesRegistry, s.lockFile} {
+		if file != nil {
+			_ = file.Close()
+		}
+	}
 
-	for number := 1; number <= s.filesCount(); number++ {
-		file, _ := s.read.readableFiles[number]
-		_ = file.Close()
+	for _, file := range s.files {
+		_ = file.reader.Close()
 	}
 }
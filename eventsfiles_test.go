@@ -2,15 +2,15 @@ package eventstorage
 
 import (
 	"bytes"
-	"strings"
 	"testing"
 )
 
 func Test_eventStorage_initRegistryFile(t *testing.T) {
 	s := &EventStorage{
 		basePath: t.TempDir(),
+		fsys:     OSFS{},
 		write:    &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:     &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:     &read{},
 	}
 
 	t.Cleanup(s.Shutdown)
@@ -24,8 +24,9 @@ func Test_eventStorage_initRegistryFile(t *testing.T) {
 func Test_eventStorage_initRegistryFileFailed(t *testing.T) {
 	s := &EventStorage{
 		basePath: string([]byte{0}),
+		fsys:     OSFS{},
 		write:    &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:     &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:     &read{},
 	}
 	t.Cleanup(s.Shutdown)
 
@@ -37,8 +38,9 @@ func Test_eventStorage_initRegistryFileFailed(t *testing.T) {
 func Test_eventStorage_appendInRegistryFile(t *testing.T) {
 	s := &EventStorage{
 		basePath: t.TempDir(),
+		fsys:     OSFS{},
 		write:    &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:     &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:     &read{},
 	}
 	t.Cleanup(s.Shutdown)
 	_ = s.initFilesRegistry()
@@ -52,16 +54,16 @@ func Test_eventStorage_appendInRegistryFile(t *testing.T) {
 	_ = file3.Close()
 	_ = s.filesRegistry.Close()
 
-	if len(s.read.readableFiles) != 3 {
+	if len(s.files) != 3 {
 		t.Errorf("appendInRegistryFile has wrong count")
 		return
 	}
 
-	for i, file := range s.read.readableFiles {
-		info, _ := file.Stat()
+	for _, file := range s.files {
+		info, _ := file.reader.Stat()
 
-		if s.getFileName(i) != info.Name() {
-			t.Errorf("Wrong file name, expected %v, got %v", s.getFileName(i), info.Name())
+		if s.getFileName(file.number) != info.Name() {
+			t.Errorf("Wrong file name, expected %v, got %v", s.getFileName(file.number), info.Name())
 			return
 		}
 	}
@@ -70,7 +72,7 @@ func Test_eventStorage_appendInRegistryFile(t *testing.T) {
 func Test_eventStorage_initLogFileWithoutRegistry(t *testing.T) {
 	s := &EventStorage{
 		write: &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:  &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:  &read{},
 	}
 
 	if err := s.initEventsFile(); err == nil {
@@ -83,8 +85,9 @@ func Test_eventStorage_initLogFileWithoutRegistry(t *testing.T) {
 func Test_eventStorage_initLogFile(t *testing.T) {
 	s := &EventStorage{
 		basePath: t.TempDir(),
+		fsys:     OSFS{},
 		write:    &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:     &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:     &read{},
 	}
 	_ = s.initFilesRegistry()
 
@@ -99,8 +102,9 @@ func Test_eventStorage_initLogFile(t *testing.T) {
 func Test_eventStorage_initLogFileFailed(t *testing.T) {
 	s := &EventStorage{
 		basePath: t.TempDir(),
+		fsys:     OSFS{},
 		write:    &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:     &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:     &read{},
 	}
 
 	_ = s.initFilesRegistry()
@@ -117,7 +121,7 @@ func Test_eventStorage_initLogFileFailed(t *testing.T) {
 func Test_eventStorage_rotateLogFileFailedCloseOld(t *testing.T) {
 	s := &EventStorage{
 		write: &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:  &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:  &read{},
 	}
 
 	if err := s.rotateEventsFile(); err == nil {
@@ -130,8 +134,9 @@ func Test_eventStorage_rotateLogFileFailedCloseOld(t *testing.T) {
 func Test_eventStorage_rotateLogFile(t *testing.T) {
 	s := &EventStorage{
 		basePath: t.TempDir(),
+		fsys:     OSFS{},
 		write:    &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:     &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:     &read{},
 	}
 
 	_ = s.initFilesRegistry()
@@ -144,16 +149,16 @@ func Test_eventStorage_rotateLogFile(t *testing.T) {
 		return
 	}
 
-	if len(s.read.readableFiles) != 2 {
+	if len(s.files) != 2 {
 		t.Errorf("rotateLogFile has wrong count")
 		return
 	}
 
-	for i, file := range s.read.readableFiles {
-		info, _ := file.Stat()
+	for _, file := range s.files {
+		info, _ := file.reader.Stat()
 
-		if s.getFileName(i) != info.Name() {
-			t.Errorf("Wrong file name, expected %v, got %v", s.getFileName(i), info.Name())
+		if s.getFileName(file.number) != info.Name() {
+			t.Errorf("Wrong file name, expected %v, got %v", s.getFileName(file.number), info.Name())
 			return
 		}
 	}
@@ -162,7 +167,7 @@ func Test_eventStorage_rotateLogFile(t *testing.T) {
 func Test_eventStorage_openLogFileFailedAppend(t *testing.T) {
 	s := &EventStorage{
 		write: &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:  &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:  &read{},
 	}
 	t.Cleanup(s.Shutdown)
 
@@ -174,7 +179,7 @@ func Test_eventStorage_openLogFileFailedAppend(t *testing.T) {
 func Test_eventStorage_SetLogFileSize(t *testing.T) {
 	s := &EventStorage{
 		write: &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:  &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:  &read{},
 	}
 	s.SetWriteFileMaxSize(100)
 	t.Cleanup(s.Shutdown)
@@ -201,3 +206,24 @@ func Test_eventStorage_calculateLogFileSize(t *testing.T) {
 		t.Errorf("calculateWriteFileSize failed")
 	}
 }
+
+func Test_eventStorage_Rotate(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	if err := storage.Rotate(); err != nil || len(storage.Files()) != 1 {
+		t.Errorf("Rotate expect empty current file kept, got %v files, err: %v", len(storage.Files()), err)
+	}
+
+	_, _ = storage.Write([]byte("event"))
+
+	if err := storage.Rotate(); err != nil {
+		t.Fatalf("Rotate failed, err: %v", err)
+	}
+
+	files := storage.Files()
+
+	if len(files) != 2 || files[0].Name != "events.1" || files[0].Count != 1 || files[1].Name != "events.2" || files[1].FirstOffset != 1 {
+		t.Errorf("Rotate expect flushed events.1 and new events.2, got %+v", files)
+	}
+}
@@ -0,0 +1,292 @@
+package eventstorage
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+)
+
+// loadIndex reads events file index and counts file events.
+// Missing or outdated index is rebuilt from events file data, read-only storage keeps files as is.
+func (s *EventStorage) loadIndex(file *eventsFile, isLast bool) error {
+	raw, err := s.readFile(s.getIndexPath(file.number))
+
+	if err != nil && !os.IsNotExist(err) {
+		return errors.New("failed to read index file: " + err.Error())
+	}
+
+	if file.compressed {
+		// Size and count of compressed file are saved in registry, complete index needs no scan.
+		if file.positions, file.times = decodeIndex(raw, file.size, s.codec.timestamps); len(file.positions) == (file.count+indexInterval-1)/indexInterval {
+			file.blocks = s.loadBlocks(file)
+			return nil
+		}
+	} else {
+		info, err := file.reader.Stat()
+
+		if err != nil {
+			return errors.New("failed to stat events file: " + err.Error())
+		}
+
+		file.size = info.Size()
+
+		if isLast {
+			// Lines of incomplete batch look like complete events, so batch file tells where it begins.
+			if start := s.tornBatchStart(file); start >= 0 && s.readOnly {
+				file.trim(start)
+			} else if start >= 0 {
+				if err = s.truncateTornEvent(file, start); err != nil {
+					return err
+				}
+			}
+		}
+
+		file.positions, file.times = decodeIndex(raw, file.size, s.codec.timestamps)
+	}
+
+	block := len(file.positions) - 1
+
+	if block < 0 {
+		block = 0
+	}
+
+	scan, err := s.scanEvents(file, block)
+
+	// Incomplete batch of the last file may begin before the scanned index block.
+	for err == nil && isLast && scan.batchStart == scan.start && block > 0 {
+		block--
+		scan, err = s.scanEvents(file, block)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !isLast && scan.end != file.size {
+		// Events of sealed file are already returned by offsets, so dropping them would shift offsets of next files.
+		return &CorruptionError{File: file.number, Position: scan.end, Err: io.ErrUnexpectedEOF}
+	}
+
+	if isLast {
+		validSize := scan.end
+
+		if scan.batchStart >= 0 {
+			validSize = scan.batchStart
+			file.count = scan.batchCount
+		}
+
+		if validSize < file.size && s.readOnly {
+			// Incomplete events are not flushed by writer yet.
+			file.trim(validSize)
+		} else if validSize < file.size {
+			if err = s.truncateTornEvent(file, validSize); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !s.readOnly && len(encodeIndex(nil, file.positions, file.times)) != len(raw) {
+		if err = s.writeFile(s.getIndexPath(file.number), encodeIndex(nil, file.positions, file.times)); err != nil {
+			return errors.New("failed to rebuild index file: " + err.Error())
+		}
+	}
+
+	if file.compressed {
+		file.blocks = s.loadBlocks(file)
+	}
+
+	if isLast && !s.readOnly {
+		return s.clearBatch()
+	}
+
+	return nil
+}
+
+// eventsScan is result of events file scan.
+type eventsScan struct {
+	start      int64 // Position of scan start.
+	end        int64 // Position after the last complete event.
+	batchStart int64 // Position of incomplete trailing batch, -1 if there is no such batch.
+	batchCount int   // Count of file events before incomplete trailing batch.
+}
+
+// scanEvents counts file events starting from index block and adds index entries after it.
+// Scan stops at incomplete event, broken event length is reported by CorruptionError.
+func (s *EventStorage) scanEvents(file *eventsFile, block int) (scan eventsScan, err error) {
+	if block < len(file.positions) {
+		scan.start = file.positions[block]
+		file.positions = file.positions[:block]
+
+		if file.times != nil {
+			file.times = file.times[:block]
+		}
+	}
+
+	file.count = block * indexInterval
+	scan.batchStart = -1
+	reader := newRecordReader(make([]byte, readBufLimit), s.codec)
+	src, err := reader.open(*file, scan.start)
+
+	if err != nil {
+		return scan, err
+	}
+
+	reader.reset(file.number, src, scan.start, file.size)
+
+	for {
+		recordPos := reader.pos
+
+		if _, err = reader.nextRaw(); err != nil {
+			break
+		}
+
+		if file.count%indexInterval == 0 {
+			file.positions = append(file.positions, recordPos)
+
+			if s.codec.timestamps {
+				file.times = append(file.times, reader.time)
+			}
+		}
+
+		if !reader.continued {
+			scan.batchStart = -1
+		} else if scan.batchStart < 0 {
+			scan.batchStart = recordPos
+			scan.batchCount = file.count
+		}
+
+		file.lastTime = reader.time
+		file.count++
+	}
+
+	scan.end = reader.pos
+
+	if errors.Is(err, ErrChecksumMismatch) {
+		return scan, err
+	} else if err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return scan, errors.New("failed to read events file: " + err.Error())
+	}
+
+	return scan, nil
+}
+
+func (s *EventStorage) loadIndexes() error {
+	firstOffset := s.baseOffset
+
+	for i, file := range s.files {
+		if err := s.loadIndex(file, i == len(s.files)-1); err != nil {
+			return err
+		}
+
+		file.firstOffset = firstOffset
+		firstOffset += file.count
+	}
+
+	return nil
+}
+
+func (s *EventStorage) openIndexFile(number int) (File, error) {
+	return s.fsys.OpenFile(s.getIndexPath(number), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// commitFlushed makes flushed events of current file visible for readers.
+func (s *EventStorage) commitFlushed() {
+	s.filesLocker.Lock()
+	defer s.filesLocker.Unlock()
+
+	file := s.files[len(s.files)-1]
+	file.count = s.write.fileEvents
+	file.size = s.write.fileSize
+	file.positions = append(file.positions, s.write.positions...)
+	file.times = append(file.times, s.write.times...)
+	file.lastTime = s.write.lastTime
+	s.write.positions = s.write.positions[:0]
+	s.write.times = s.write.times[:0]
+	s.notifyFlushed()
+}
+
+// findFile returns copy of events file, which contains event with offset.
+// It returns io.EOF for offset after the last flushed event.
+func (s *EventStorage) findFile(offset int) (eventsFile, error) {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	i := sort.Search(len(s.files), func(i int) bool {
+		return s.files[i].firstOffset+s.files[i].count > offset
+	})
+
+	if i == len(s.files) {
+		return eventsFile{}, io.EOF
+	}
+
+	if offset < s.files[i].firstOffset {
+		return eventsFile{}, ErrOffsetRemoved
+	}
+
+	return *s.files[i], nil
+}
+
+// seek prepares reader to read file events starting from event with file offset.
+func (r *recordReader) seek(file eventsFile, fileOffset int) error {
+	pos := file.positions[fileOffset/indexInterval]
+	src, err := r.open(file, pos)
+
+	if err != nil {
+		return err
+	}
+
+	r.reset(file.number, src, pos, file.size)
+
+	return r.skip(fileOffset % indexInterval)
+}
+
+func (s *EventStorage) getIndexPath(number int) string {
+	return s.getFilePath(s.getFileName(number) + indexFileSuffix)
+}
+
+// encodeIndex appends index entries, entry is event position and its write time with timestamps enabled.
+func encodeIndex(raw []byte, positions []int64, times []int64) []byte {
+	var value [indexEntrySize]byte
+
+	for i, pos := range positions {
+		binary.LittleEndian.PutUint64(value[:], uint64(pos))
+		raw = append(raw, value[:]...)
+
+		if times != nil {
+			binary.LittleEndian.PutUint64(value[:], uint64(times[i]))
+			raw = append(raw, value[:]...)
+		}
+	}
+
+	return raw
+}
+
+// decodeIndex skips broken tail of index and positions out of events file size.
+func decodeIndex(raw []byte, size int64, withTimes bool) (positions []int64, times []int64) {
+	entrySize := indexEntrySize
+
+	if withTimes {
+		entrySize *= 2
+		times = make([]int64, 0, len(raw)/entrySize)
+	}
+
+	positions = make([]int64, 0, len(raw)/entrySize)
+
+	for i := 0; i+entrySize <= len(raw); i += entrySize {
+		pos := int64(binary.LittleEndian.Uint64(raw[i:]))
+
+		if pos >= size {
+			break
+		}
+
+		positions = append(positions, pos)
+
+		if withTimes {
+			times = append(times, int64(binary.LittleEndian.Uint64(raw[i+indexEntrySize:])))
+		}
+	}
+
+	return positions, times
+}
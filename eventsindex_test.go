@@ -0,0 +1,131 @@
+package eventstorage
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func Test_eventStorage_indexMaintainedOnFlush(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	for i := 0; i < indexInterval*2+1; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	if len(storage.files[0].positions) != 0 {
+		t.Errorf("indexMaintainedOnFlush expect not flushed positions are invisible, got %v", len(storage.files[0].positions))
+		return
+	}
+
+	_, _ = storage.Flush()
+
+	if len(storage.files[0].positions) != 3 {
+		t.Errorf("indexMaintainedOnFlush expect 3 positions, got %v", len(storage.files[0].positions))
+		return
+	}
+
+	raw, _ := os.ReadFile(storage.getIndexPath(1))
+	positions, _ := decodeIndex(raw, storage.files[0].size, false)
+
+	for i, pos := range storage.files[0].positions {
+		if positions[i] != pos {
+			t.Errorf("indexMaintainedOnFlush persisted position %v not equal %v", positions[i], pos)
+			return
+		}
+	}
+}
+
+func Test_eventStorage_loadIndexRebuild(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+
+	for i := 0; i < indexInterval*3; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	expectedPositions := storage.files[0].positions
+	_ = os.Remove(storage.getIndexPath(1))
+
+	storage, err := New(path)
+
+	if err != nil {
+		t.Errorf("loadIndexRebuild failed, err: " + err.Error())
+		return
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if storage.files[0].count != indexInterval*3 {
+		t.Errorf("loadIndexRebuild expect %v events, got %v", indexInterval*3, storage.files[0].count)
+		return
+	}
+
+	for i, pos := range storage.files[0].positions {
+		if expectedPositions[i] != pos {
+			t.Errorf("loadIndexRebuild position %v not equal %v", pos, expectedPositions[i])
+			return
+		}
+	}
+
+	if _, err = os.Stat(storage.getIndexPath(1)); err != nil {
+		t.Errorf("loadIndexRebuild expect index file written, err: " + err.Error())
+	}
+}
+
+func Test_eventStorage_ReadToWithIndexAcrossFiles(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	storage.SetWriteFileMaxSize(20 * KB)
+
+	const iterCount = indexInterval * 5
+
+	for i := 0; i < iterCount; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	storage, _ = New(path)
+	t.Cleanup(storage.Shutdown)
+
+	if len(storage.files) < 2 {
+		t.Errorf("ReadToWithIndexAcrossFiles expect rotated files, got %v", len(storage.files))
+		return
+	}
+
+	for _, offset := range []int{0, indexInterval - 1, indexInterval, indexInterval*3 + 7, iterCount - 1} {
+		events, err := storage.Read(1, offset)
+
+		if err != nil || len(events) != 1 || events[0] != "event "+strconv.Itoa(offset) {
+			t.Errorf("ReadToWithIndexAcrossFiles at offset %v got %q, err: %v", offset, events, err)
+		}
+	}
+
+	if events, _ := storage.Read(1, iterCount); len(events) != 0 {
+		t.Errorf("ReadToWithIndexAcrossFiles expect nothing out of range, got %q", events)
+	}
+}
+
+func Test_decodeIndex(t *testing.T) {
+	raw := encodeIndex(nil, []int64{0, 10, 20, 30}, nil)
+
+	if positions, _ := decodeIndex(raw[:len(raw)-1], 100, false); len(positions) != 3 {
+		t.Errorf("decodeIndex expect broken entry skipped, got %v", positions)
+	}
+
+	if positions, _ := decodeIndex(raw, 25, false); len(positions) != 3 {
+		t.Errorf("decodeIndex expect position out of size skipped, got %v", positions)
+	}
+
+	raw = encodeIndex(nil, []int64{0, 10}, []int64{100, 200})
+
+	if positions, times := decodeIndex(raw, 100, true); len(positions) != 2 || times[1] != 200 {
+		t.Errorf("decodeIndex expect positions with times, got %v %v", positions, times)
+	}
+}
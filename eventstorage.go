@@ -2,73 +2,191 @@ package eventstorage
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
-	"strings"
+	"sync/atomic"
 	"time"
 )
 
-func New(basePath string) (*EventStorage, error) {
+// New opens storage in existing base path, the base path is locked, so the second writer fails with ErrStorageLocked.
+// Lock is taken by flock on unix systems and by LockFileEx on windows, other platforms do not detect the second writer.
+func New(basePath string, options ...Option) (*EventStorage, error) {
 	s := &EventStorage{
 		basePath:  basePath,
 		write:     &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:      &read{readableFiles: make(readableFiles), buf: new(strings.Builder), readBuf: make([]byte, readBufLimit)},
 		turnedOff: make(chan bool, 1),
+		flushed:   make(chan struct{}),
 	}
 
-	if err := s.initFilesRegistry(); err != nil {
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.fsys == nil {
+		s.fsys = OSFS{}
+	}
+
+	if err := s.lockBasePath(); err != nil {
 		return nil, err
 	}
 
-	if err := s.initEventsFile(); err != nil {
+	if err := s.open(); err != nil {
+		_ = s.lockFile.Close()
 		return nil, err
 	}
 
+	return s, nil
+}
+
+// open initializes events files of locked base path.
+func (s *EventStorage) open() error {
+	if err := s.initFilesRegistry(); err != nil {
+		return err
+	}
+
+	s.read = &read{reader: newRecordReader(make([]byte, readBufLimit), s.codec)}
+
+	if err := s.initEventsFile(); err != nil {
+		return err
+	}
+
+	if err := s.loadIndexes(); err != nil {
+		return err
+	}
+
+	if err := s.loadConsumers(); err != nil {
+		return err
+	}
+
 	s.write.fileSize = s.calculateWriteFileSize()
+	s.write.fileEvents = s.files[len(s.files)-1].count
+	s.write.lastTime = s.files[len(s.files)-1].lastTime
+	s.write.offset = s.flushedOffset()
 
-	return s, nil
+	return nil
 }
 
-func (s *EventStorage) Write(data []byte) (writtenLen int64, err error) {
+// Write appends event and returns its offset, which may be used for Read and ReadTo.
+// Offset stays the same after restart, rejected event takes no offset.
+func (s *EventStorage) Write(data []byte) (offset int, err error) {
+	if s.readOnly {
+		return 0, ErrReadOnly
+	}
+
 	s.write.locker.Lock()
 	defer s.write.locker.Unlock()
 
-	s.write.buf.Write(data)
-	s.write.buf.WriteByte(LineBreak)
+	if offset, err = s.append(data, false); err != nil {
+		return 0, err
+	}
 
-	writtenLen += int64(len(data) + 1)
+	return offset, s.flushAppended()
+}
 
-	s.write.fileSize += writtenLen
-	s.write.insertsCount++
+// append writes event into buffer and returns its offset.
+// Event of lines format with line break is rejected, it would be read as several events.
+func (s *EventStorage) append(data []byte, continued bool) (offset int, err error) {
+	if err = s.codec.validate(data); err != nil {
+		return 0, err
+	}
 
-	if s.write.autoFlushCount > 0 && s.write.insertsCount >= s.write.autoFlushCount {
-		if _, err = s.flush(); err != nil {
-			return
+	if s.codec.timestamps {
+		// Write time never goes back, so time index is ordered.
+		if now := time.Now().UnixNano(); now > s.write.lastTime {
+			s.write.lastTime = now
 		}
 	}
 
-	if s.write.fileSize >= s.write.fileMaxSize {
-		if _, err = s.flush(); err != nil {
-			return
+	if s.write.fileEvents%indexInterval == 0 {
+		s.write.positions = append(s.write.positions, s.write.fileSize)
+
+		if s.codec.timestamps {
+			s.write.times = append(s.write.times, s.write.lastTime)
 		}
+	}
+
+	offset = s.write.offset
+	s.write.fileSize += s.codec.append(s.write.buf, data, s.write.lastTime, continued)
+	s.write.offset++
+	s.write.fileEvents++
+	s.write.insertsCount++
+	s.write.dataSize += int64(len(data))
+	atomic.AddUint64(&s.counters.writes, 1)
+
+	return offset, nil
+}
 
-		if err = s.rotateEventsFile(); err != nil {
+// flushAppended flushes and rotates events file after append, according to settings.
+func (s *EventStorage) flushAppended() (err error) {
+	if s.write.durability == DurabilityWrite || s.write.autoFlushCount > 0 && s.write.insertsCount >= s.write.autoFlushCount {
+		if _, err = s.flush(); err != nil {
 			return
 		}
 	}
 
+	if s.write.fileSize >= s.write.fileMaxSize {
+		return s.rotate()
+	}
+
 	return
 }
 
+// rotate flushes current events file and starts a new one.
+func (s *EventStorage) rotate() (err error) {
+	if _, err = s.flush(); err != nil {
+		return
+	}
+
+	if err = s.rotateEventsFile(); err != nil {
+		return
+	}
+
+	return s.applyRetention()
+}
+
 func (s *EventStorage) flush() (count int, err error) {
+	if s.write.insertsCount > 0 && s.write.file == nil {
+		return 0, errors.New("cant flush without events file")
+	}
+
 	if s.write.insertsCount > 0 {
+		started := time.Now()
+
+		defer func() {
+			if err != nil {
+				atomic.AddUint64(&s.counters.flushErrors, 1)
+				return
+			}
+
+			elapsed := uint64(time.Since(started))
+			atomic.AddUint64(&s.counters.flushes, 1)
+			atomic.AddUint64(&s.counters.flushNanos, elapsed)
+			atomic.StoreUint64(&s.counters.lastFlushNanos, elapsed)
+		}()
+
 		if _, err = s.write.file.Write(s.write.buf.Bytes()); err != nil {
 			return 0, errors.New("flush failed: " + err.Error())
-		} else {
-			s.write.buf.Truncate(0)
-			count = s.write.insertsCount
-			s.write.insertsCount = 0
 		}
+
+		if len(s.write.positions) > 0 {
+			if _, err = s.write.indexFile.Write(encodeIndex(nil, s.write.positions, s.write.times)); err != nil {
+				return 0, errors.New("flush index failed: " + err.Error())
+			}
+		}
+
+		if err = s.syncFlushed(); err != nil {
+			return 0, err
+		}
+
+		s.write.buf.Truncate(0)
+		s.commitFlushed()
+		count = s.write.insertsCount
+		s.write.insertsCount = 0
+		// Counters of flushed data are updated here, so append changes the only counter.
+		atomic.AddUint64(&s.counters.flushedBytes, uint64(s.write.dataSize))
+		atomic.StoreUint64(&s.counters.flushedWrites, atomic.LoadUint64(&s.counters.writes))
+		s.write.dataSize = 0
 	}
 
 	return
@@ -114,55 +232,57 @@ func (s *EventStorage) SetAutoFlushTime(period time.Duration) error {
 	return nil
 }
 
-func (s *EventStorage) ReadTo(count int, offset int, events []string) {
+// ReadTo reads up to count events starting from offset into events and returns count of read events.
+// Broken events data is reported by CorruptionError.
+func (s *EventStorage) ReadTo(count int, offset int, events []string) (int, error) {
 	s.read.locker.Lock()
 	defer s.read.locker.Unlock()
 
-	s.read.eventsCount = 0
-	s.read.eventsSaved = 0
-	s.read.buf.Reset()
+	return s.readTo(context.Background(), count, offset, events)
+}
+
+// readTo reads events under read locker, reading of events data stops when context is done.
+func (s *EventStorage) readTo(ctx context.Context, count int, offset int, events []string) (int, error) {
+	s.read.reader.ctx = ctx
+	defer func() { s.read.reader.ctx = nil }()
 
-	for number := 1; number <= s.filesCount(); number++ {
-		file := s.read.readableFiles[number]
-		s.read.seekOffset = 0
+	saved := 0
+	defer func() { atomic.AddUint64(&s.counters.reads, uint64(saved)) }()
 
-		for {
-			_, _ = file.Seek(s.read.seekOffset, 0)
-			readCount, err := file.Read(s.read.readBuf)
+	for saved < count {
+		file, err := s.findFile(offset + saved)
 
-			if err != nil && (err == io.EOF || strings.Contains(err.Error(), "file already closed")) {
-				break
-			}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return saved, err
+		}
 
-			for i := 0; i < readCount; i++ {
-				if s.read.readBuf[i] == LineBreak {
-					s.read.eventsCount++
-				} else if s.read.eventsCount >= offset {
-					s.read.buf.WriteByte(s.read.readBuf[i])
-				}
-
-				if s.read.eventsCount <= offset {
-					continue
-				}
-
-				if s.read.readBuf[i] == LineBreak {
-					events[s.read.eventsSaved] = s.read.buf.String()
-					s.read.eventsSaved++
-					s.read.buf.Reset()
-
-					if s.read.eventsSaved == count {
-						return
-					}
-				}
+		fileOffset := offset + saved - file.firstOffset
+
+		if err := s.read.reader.seek(file, fileOffset); err != nil {
+			return saved, s.read.reader.committedErr(err)
+		}
+
+		for ; saved < count && fileOffset < file.count; fileOffset++ {
+			record, err := s.read.reader.next()
+
+			if err != nil {
+				return saved, s.read.reader.committedErr(err)
 			}
 
-			s.read.seekOffset += readBufLimit
+			events[saved] = string(record)
+			saved++
 		}
 	}
+
+	return saved, nil
 }
 
-func (s *EventStorage) Read(count int, offset int) []string {
+// Read reads up to count events starting from offset.
+func (s *EventStorage) Read(count int, offset int) ([]string, error) {
 	events := make([]string, count)
-	s.ReadTo(count, offset, events)
-	return events
+	saved, err := s.ReadTo(count, offset, events)
+
+	return events[:saved], err
 }
@@ -2,10 +2,10 @@ package eventstorage
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 	"testing"
 	"time"
 )
@@ -51,14 +51,14 @@ func Test_eventStorage_WriteCheckRotate(t *testing.T) {
 		return
 	}
 
-	if len(storage.read.readableFiles) != 7 {
-		t.Errorf("WriteCheckRotate expect 7 events files, got %v", len(storage.read.readableFiles))
+	if len(storage.files) != 7 {
+		t.Errorf("WriteCheckRotate expect 7 events files, got %v", len(storage.files))
 		return
 	}
 
-	for i, file := range storage.read.readableFiles {
-		info, _ := file.Stat()
-		expectedName := fmt.Sprintf(eventsFileNameTemplate, i)
+	for _, file := range storage.files {
+		info, _ := file.reader.Stat()
+		expectedName := fmt.Sprintf(eventsFileNameTemplate, file.number)
 		if info.Name() != expectedName {
 			t.Errorf("WriteCheckRotate not equal expected events file name (%v), got %v", expectedName, info.Name())
 		}
@@ -79,7 +79,13 @@ func Test_eventStorage_Read(t *testing.T) {
 		_, _ = storage.Write([]byte(dataPrefix + strconv.Itoa(i)))
 	}
 
-	events := storage.Read(iterCount-offset, offset)
+	events, err := storage.Read(iterCount-offset, offset)
+
+	if err != nil || len(events) != iterCount-offset {
+		t.Errorf("Read failed, got %v events, err: %v", len(events), err)
+		return
+	}
+
 	for i, event := range events {
 		if event != dataPrefix+strconv.Itoa(offset+i) {
 			t.Errorf("Read failed, incorrect data.")
@@ -88,6 +94,29 @@ func Test_eventStorage_Read(t *testing.T) {
 	}
 }
 
+func Test_eventStorage_WriteLineBreak(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	if _, err := storage.Write([]byte("a\nb")); err != ErrLineBreakInEvent {
+		t.Errorf("Write expect %v, got %v", ErrLineBreakInEvent, err)
+	}
+
+	if _, err := storage.WriteContext(context.Background(), []byte("a\n")); err != ErrLineBreakInEvent {
+		t.Errorf("WriteContext expect %v, got %v", ErrLineBreakInEvent, err)
+	}
+
+	if offset, err := storage.Write([]byte("c")); err != nil || offset != 0 {
+		t.Errorf("Write expect offset 0 after rejected events, got %v, err: %v", offset, err)
+	}
+
+	_, _ = storage.Flush()
+
+	if events, err := storage.Read(10, 0); err != nil || !reflect.DeepEqual(events, []string{"c"}) {
+		t.Errorf("Read expect only accepted event, got %v, err: %v", events, err)
+	}
+}
+
 func Test_eventStorage_autoFlushCount(t *testing.T) {
 	storage, _ := New(t.TempDir())
 	storage.SetAutoFlushCount(1)
@@ -103,7 +132,7 @@ func Test_eventStorage_autoFlushCount(t *testing.T) {
 func Test_eventStorage_autoFlushCountFailedFlush(t *testing.T) {
 	storage := EventStorage{
 		write: &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:  &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:  &read{},
 	}
 
 	storage.SetAutoFlushCount(1)
@@ -117,7 +146,7 @@ func Test_eventStorage_autoFlushCountFailedFlush(t *testing.T) {
 func Test_eventStorage_WriteFailedRotateFlush(t *testing.T) {
 	storage := EventStorage{
 		write: &write{buf: new(bytes.Buffer), fileMaxSize: 1},
-		read:  &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:  &read{},
 	}
 
 	t.Cleanup(storage.Shutdown)
@@ -132,7 +161,7 @@ func Test_eventStorage_WriteFailedRotateFlush(t *testing.T) {
 func Test_eventStorage_autoFlushCountSetterGetter(t *testing.T) {
 	storage := EventStorage{
 		write: &write{buf: new(bytes.Buffer), fileMaxSize: 100 * MB},
-		read:  &read{readableFiles: make(readableFiles), buf: new(strings.Builder)},
+		read:  &read{},
 	}
 	storage.SetAutoFlushCount(7)
 
@@ -173,7 +202,7 @@ func Test_eventStorage_SetAutoFlushTime(t *testing.T) {
 	_, _ = storage.Write(data)
 	time.Sleep(time.Millisecond * 100)
 
-	events := storage.Read(1, 0)
+	events, _ := storage.Read(1, 0)
 
 	if len(events) == 0 {
 		t.Errorf("SetAutoFlushTime failed, fetched data is incorrect")
@@ -209,7 +238,7 @@ func BenchmarkEventStorage_ReadChar(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		storage.Read(1, 0)
+		_, _ = storage.Read(1, 0)
 	}
 }
 
@@ -222,7 +251,7 @@ func BenchmarkEventStorage_CharReadTo(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		storage.ReadTo(1, 0, readTo)
+		_, _ = storage.ReadTo(1, 0, readTo)
 	}
 }
 
@@ -234,7 +263,7 @@ func BenchmarkEventStorage_CharReadToOffset10000(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		storage.ReadTo(1, 10000, readTo)
+		_, _ = storage.ReadTo(1, 10000, readTo)
 	}
 }
 
@@ -256,3 +285,54 @@ func benchmarksInitStorage(b *testing.B) *EventStorage {
 
 	return storage
 }
+
+func Test_eventStorage_WriteOffset(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	storage.SetWriteFileMaxSize(20)
+
+	for i := 0; i < 5; i++ {
+		if offset, _ := storage.Write([]byte("event " + strconv.Itoa(i))); offset != i {
+			t.Errorf("WriteOffset expect offset %v, got %v", i, offset)
+		}
+	}
+
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	storage, _ = New(path)
+	t.Cleanup(storage.Shutdown)
+
+	offset, err := storage.Write([]byte("after restart"))
+	_, _ = storage.Flush()
+
+	if err != nil || offset != 5 {
+		t.Errorf("WriteOffset expect offset 5 after restart, got %v, err: %v", offset, err)
+		return
+	}
+
+	if events, _ := storage.Read(1, offset); len(events) != 1 || events[0] != "after restart" {
+		t.Errorf("WriteOffset expect event by returned offset, got %q", events)
+	}
+}
+
+func Test_eventStorage_WriteOffsetRejectedEvent(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	_, _ = storage.Write([]byte("first"))
+	_, _ = storage.Write([]byte("broken\nevent"))
+	second, _ := storage.Write([]byte("second"))
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	storage, _ = New(path)
+	t.Cleanup(storage.Shutdown)
+
+	if offset, _ := storage.Write([]byte("after restart")); second != 1 || offset != 2 {
+		t.Errorf("WriteOffset expect offsets 1 and 2 to stay after restart, got %v and %v", second, offset)
+	}
+
+	if events, _ := storage.Read(1, second); len(events) != 1 || events[0] != "second" {
+		t.Errorf("WriteOffset expect event by returned offset, got %q", events)
+	}
+}
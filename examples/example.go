@@ -24,7 +24,14 @@ func main() {
 		return
 	}
 
-	fmt.Println(storage.Read(1, 0))
+	events, err := storage.Read(1, 0)
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(events)
 }
 
 func readOffset() {
@@ -33,9 +40,9 @@ func readOffset() {
 
 	to := make([]string, 2)
 
-	storage.ReadTo(2, 10, to)
+	count, _ := storage.ReadTo(2, 10, to)
 
-	fmt.Println(to)
+	fmt.Println(to[:count])
 }
 
 func fillManyFilesAndRead() {
@@ -52,7 +59,7 @@ func fillManyFilesAndRead() {
 
 	time.Sleep(time.Second)
 
-	events := storage.Read(10, 1000001)
+	events, _ := storage.Read(10, 1000001)
 	for _, event := range events {
 		fmt.Println(event)
 	}
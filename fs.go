@@ -0,0 +1,159 @@
+package eventstorage
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// File is storage file opened by FS, *os.File implements it.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.Closer
+	Stat() (fs.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
+}
+
+// FS is file system of storage files, names are paths joined by os.PathSeparator.
+// Missing files are reported by errors matching fs.ErrNotExist.
+type FS interface {
+	Open(name string) (File, error)                                 // Opens file for read.
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error) // Opens or creates file by os.O_* flags.
+	Remove(name string) error                                       // Removes file or empty directory.
+	RemoveAll(name string) error                                    // Removes directory with its content.
+	Rename(oldName string, newName string) error                    // Replaces file atomically.
+	Stat(name string) (fs.FileInfo, error)                          // Describes file or directory.
+	ReadDir(name string) ([]fs.DirEntry, error)                     // Lists directory sorted by names.
+	MkdirAll(name string, perm fs.FileMode) error                   // Creates directory with parents.
+	SyncDir(name string) error                                      // Makes directory entries durable.
+	Lock(name string) (io.Closer, error)                            // Takes exclusive lock of file, ErrStorageLocked if it is taken.
+}
+
+// OSFS is FS of local disk, it is default FS of storage.
+type OSFS struct{}
+
+func (OSFS) Open(name string) (File, error) {
+	return openOSFile(name, os.O_RDONLY, 0)
+}
+
+func (OSFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	return openOSFile(name, flag, perm)
+}
+
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFS) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+func (OSFS) Rename(oldName string, newName string) error {
+	return os.Rename(oldName, newName)
+}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OSFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (OSFS) SyncDir(name string) error {
+	return syncDir(name)
+}
+
+// Lock takes advisory lock, it is released on close or by exit of process.
+func (OSFS) Lock(name string) (io.Closer, error) {
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lockExclusive(file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// openOSFile returns nil interface on error, so callers never get typed nil file.
+func openOSFile(name string, flag int, perm fs.FileMode) (File, error) {
+	file, err := os.OpenFile(name, flag, perm)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// WithFS sets file system of storage files, OSFS is used by default.
+func WithFS(fsys FS) Option {
+	return func(s *EventStorage) {
+		s.fsys = fsys
+	}
+}
+
+// optionsFS returns FS set by options, options only set storage fields, so they are applied to a probe storage.
+func optionsFS(options []Option) FS {
+	probe := &EventStorage{}
+
+	for _, option := range options {
+		option(probe)
+	}
+
+	if probe.fsys == nil {
+		return OSFS{}
+	}
+
+	return probe.fsys
+}
+
+// readFile reads whole storage file like os.ReadFile.
+func (s *EventStorage) readFile(name string) ([]byte, error) {
+	return readFSFile(s.fsys, name)
+}
+
+// writeFile replaces content of storage file like os.WriteFile.
+func (s *EventStorage) writeFile(name string, data []byte) error {
+	return writeFSFile(s.fsys, name, data)
+}
+
+func readFSFile(fsys FS, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+func writeFSFile(fsys FS, name string, data []byte) error {
+	file, err := fsys.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
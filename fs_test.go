@@ -0,0 +1,83 @@
+package eventstorage
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// failingFS is local disk FS, which fails operations on files with name suffix.
+type failingFS struct {
+	OSFS
+	suffix string
+	opened []string
+}
+
+var errInjected = errors.New("injected failure")
+
+func (f *failingFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f.opened = append(f.opened, name)
+
+	if strings.HasSuffix(name, f.suffix) {
+		return nil, errInjected
+	}
+
+	return f.OSFS.OpenFile(name, flag, perm)
+}
+
+func (f *failingFS) Rename(oldName string, newName string) error {
+	if strings.HasSuffix(newName, f.suffix) {
+		return errInjected
+	}
+
+	return f.OSFS.Rename(oldName, newName)
+}
+
+func Test_eventStorage_WithFS(t *testing.T) {
+	fsys := &failingFS{suffix: "events.2"}
+	storage, err := New(t.TempDir(), WithFS(fsys))
+
+	if err != nil {
+		t.Fatalf("New expect no error, got %v", err)
+	}
+
+	t.Cleanup(storage.Shutdown)
+	storage.SetWriteFileMaxSize(5)
+
+	if len(fsys.opened) == 0 {
+		t.Errorf("New expect files opened by FS")
+	}
+
+	if _, err = storage.Write([]byte("event")); err == nil || !strings.Contains(err.Error(), errInjected.Error()) {
+		t.Errorf("Write expect rotation failure %v, got %v", errInjected, err)
+	}
+
+	if events, err := storage.Read(1, 0); err != nil || len(events) != 1 || events[0] != "event" {
+		t.Errorf("Read expect flushed event, got %v, err: %v", events, err)
+	}
+}
+
+func Test_eventStorage_WithFSFailedCommit(t *testing.T) {
+	storage, _ := New(t.TempDir(), WithFS(&failingFS{suffix: consumersFileName}))
+	t.Cleanup(storage.Shutdown)
+
+	if err := storage.Commit("consumer", 1); err == nil || !strings.Contains(err.Error(), errInjected.Error()) {
+		t.Errorf("Commit expect failure %v, got %v", errInjected, err)
+	}
+}
+
+func Test_Storage_WithFS(t *testing.T) {
+	fsys := &failingFS{suffix: tmpFileSuffix}
+	st, err := NewStorage(t.TempDir(), WithFS(fsys))
+
+	if err != nil {
+		t.Fatalf("NewStorage expect no error, got %v", err)
+	}
+
+	t.Cleanup(st.Shutdown)
+
+	if _, err = st.Write("orders", []byte("event")); err != nil || len(fsys.opened) == 0 {
+		t.Errorf("Write expect stream files opened by FS of options, got %v, err: %v", fsys.opened, err)
+	}
+}
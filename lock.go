@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || illumos
+
+package eventstorage
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockExclusive takes advisory exclusive lock of file without waiting.
+func lockExclusive(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+
+	if err == syscall.EWOULDBLOCK {
+		return ErrStorageLocked
+	}
+
+	return err
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || illumos || windows)
+
+package eventstorage
+
+import "os"
+
+// lockExclusive does nothing, the platform has no flock, so the second writer is not detected.
+func lockExclusive(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package eventstorage
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = syscall.Errno(33)
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+// lockExclusive takes exclusive lock of the first byte of file by LockFileEx without waiting,
+// lock is released when file is closed.
+func lockExclusive(file *os.File) error {
+	var overlapped syscall.Overlapped
+
+	ok, _, err := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+
+	if ok != 0 {
+		return nil
+	}
+
+	if err == errorLockViolation {
+		return ErrStorageLocked
+	}
+
+	return err
+}
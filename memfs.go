@@ -0,0 +1,515 @@
+package eventstorage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const memoryBasePath = "events" // Base path of storage created by NewMemory.
+
+var errDirectoryNotEmpty = errors.New("directory not empty")
+
+// NewMemory returns storage, which keeps its files in memory and never touches disk.
+// Files are lost with storage, so it suits tests and short-lived in-process buffering.
+// FS of options is replaced by a new MemFS.
+func NewMemory(options ...Option) (*EventStorage, error) {
+	fsys := NewMemFS()
+
+	if err := fsys.MkdirAll(memoryBasePath, 0755); err != nil {
+		return nil, err
+	}
+
+	return New(memoryBasePath, append(options[:len(options):len(options)], WithFS(fsys))...)
+}
+
+// MemFS is FS keeping files in memory. Like on disk, removed or renamed files stay readable
+// by opened handles, so storage behaves the same way as with OSFS. MemFS is safe for concurrent use.
+type MemFS struct {
+	files  map[string]*memData // Files by cleaned paths.
+	dirs   map[string]bool     // Directories by cleaned paths.
+	locked map[string]bool     // Paths locked by Lock.
+	locker sync.Mutex          // Files, directories and locks lock.
+}
+
+// memData is content of memory file, shared by all its handles.
+type memData struct {
+	data    []byte
+	modTime time.Time
+	locker  sync.RWMutex
+}
+
+// memFile is opened handle of memory file.
+type memFile struct {
+	name   string
+	data   *memData
+	flag   int
+	pos    int64 // Position of the next Read and of the next Write without os.O_APPEND.
+	closed bool
+	locker sync.Mutex
+}
+
+// memFileInfo describes memory file or directory.
+type memFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+// memLock releases lock of MemFS path on close.
+type memLock struct {
+	fsys *MemFS
+	name string
+	once sync.Once
+}
+
+// NewMemFS returns empty memory file system, it has the root and the current directories only.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		files:  make(map[string]*memData),
+		dirs:   map[string]bool{".": true, string(os.PathSeparator): true},
+		locked: make(map[string]bool),
+	}
+}
+
+func (m *MemFS) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	return m.openFile(filepath.Clean(name), flag)
+}
+
+// openFile opens or creates file of cleaned path, it must be called under locker.
+func (m *MemFS) openFile(name string, flag int) (*memFile, error) {
+	if m.dirs[name] {
+		if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+		}
+
+		return &memFile{name: name, data: &memData{}, flag: flag}, nil
+	}
+
+	data, ok := m.files[name]
+
+	if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+
+		if !m.dirs[filepath.Dir(name)] {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+
+		data = &memData{modTime: time.Now()}
+		m.files[name] = data
+	} else if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+
+	if flag&os.O_TRUNC != 0 && flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		data.locker.Lock()
+		data.data = nil
+		data.modTime = time.Now()
+		data.locker.Unlock()
+	}
+
+	return &memFile{name: name, data: data, flag: flag}, nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	name = filepath.Clean(name)
+
+	if _, ok := m.files[name]; ok {
+		delete(m.files, name)
+		return nil
+	}
+
+	if !m.dirs[name] {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if len(m.children(name)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errDirectoryNotEmpty}
+	}
+
+	delete(m.dirs, name)
+
+	return nil
+}
+
+func (m *MemFS) RemoveAll(name string) error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	name = filepath.Clean(name)
+	prefix := name + string(os.PathSeparator)
+	delete(m.files, name)
+	delete(m.dirs, name)
+
+	for path := range m.files {
+		if strings.HasPrefix(path, prefix) {
+			delete(m.files, path)
+		}
+	}
+
+	for path := range m.dirs {
+		if strings.HasPrefix(path, prefix) {
+			delete(m.dirs, path)
+		}
+	}
+
+	return nil
+}
+
+// Rename replaces file, directories are not renamed.
+func (m *MemFS) Rename(oldName string, newName string) error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	oldName, newName = filepath.Clean(oldName), filepath.Clean(newName)
+	data, ok := m.files[oldName]
+
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrNotExist}
+	}
+
+	if !m.dirs[filepath.Dir(newName)] || m.dirs[newName] {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrInvalid}
+	}
+
+	delete(m.files, oldName)
+	m.files[newName] = data
+
+	return nil
+}
+
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	return m.stat(filepath.Clean(name))
+}
+
+// stat describes cleaned path, it must be called under locker.
+func (m *MemFS) stat(name string) (fs.FileInfo, error) {
+	if m.dirs[name] {
+		return &memFileInfo{name: filepath.Base(name), dir: true}, nil
+	}
+
+	if data, ok := m.files[name]; ok {
+		return data.info(filepath.Base(name)), nil
+	}
+
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	name = filepath.Clean(name)
+
+	if !m.dirs[name] {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	children := m.children(name)
+	entries := make([]fs.DirEntry, 0, len(children))
+
+	for _, child := range children {
+		info, _ := m.stat(child)
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// children returns paths of directory files and subdirectories, it must be called under locker.
+func (m *MemFS) children(dir string) []string {
+	var children []string
+
+	for path := range m.files {
+		if filepath.Dir(path) == dir {
+			children = append(children, path)
+		}
+	}
+
+	for path := range m.dirs {
+		if path != dir && filepath.Dir(path) == dir {
+			children = append(children, path)
+		}
+	}
+
+	return children
+}
+
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	var created []string
+
+	for path := filepath.Clean(name); !m.dirs[path]; path = filepath.Dir(path) {
+		if _, ok := m.files[path]; ok {
+			return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrExist}
+		}
+
+		created = append(created, path)
+	}
+
+	for _, path := range created {
+		m.dirs[path] = true
+	}
+
+	return nil
+}
+
+// SyncDir does nothing but checks that directory exists, memory is never synced.
+func (m *MemFS) SyncDir(name string) error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	if !m.dirs[filepath.Clean(name)] {
+		return &fs.PathError{Op: "sync", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return nil
+}
+
+// Lock creates lock file like OSFS and takes exclusive lock of it until close.
+func (m *MemFS) Lock(name string) (io.Closer, error) {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	name = filepath.Clean(name)
+
+	if _, err := m.openFile(name, os.O_CREATE|os.O_RDWR); err != nil {
+		return nil, err
+	}
+
+	if m.locked[name] {
+		return nil, ErrStorageLocked
+	}
+
+	m.locked[name] = true
+
+	return &memLock{fsys: m, name: name}, nil
+}
+
+func (l *memLock) Close() error {
+	l.once.Do(func() {
+		l.fsys.locker.Lock()
+		delete(l.fsys.locked, l.name)
+		l.fsys.locker.Unlock()
+	})
+
+	return nil
+}
+
+func (d *memData) info(name string) *memFileInfo {
+	d.locker.RLock()
+	defer d.locker.RUnlock()
+
+	return &memFileInfo{name: name, size: int64(len(d.data)), modTime: d.modTime}
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if err := f.check("read", os.O_RDONLY); err != nil {
+		return 0, err
+	}
+
+	n, err := f.data.readAt(p, f.pos)
+	f.pos += int64(n)
+
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.locker.Lock()
+	err := f.check("read", os.O_RDONLY)
+	f.locker.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return f.data.readAt(p, off)
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if err := f.check("write", os.O_WRONLY); err != nil {
+		return 0, err
+	}
+
+	f.data.locker.Lock()
+	defer f.data.locker.Unlock()
+
+	if f.flag&os.O_APPEND != 0 {
+		f.pos = int64(len(f.data.data))
+	}
+
+	if end := f.pos + int64(len(p)); end > int64(len(f.data.data)) {
+		f.data.data = append(f.data.data, make([]byte, end-int64(len(f.data.data)))...)
+	}
+
+	copy(f.data.data[f.pos:], p)
+	f.pos += int64(len(p))
+	f.data.modTime = time.Now()
+
+	return len(p), nil
+}
+
+func (f *memFile) Close() error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	f.closed = true
+
+	return nil
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	return f.data.info(filepath.Base(f.name)), nil
+}
+
+// Sync does nothing, memory is never synced.
+func (f *memFile) Sync() error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if f.closed {
+		return &fs.PathError{Op: "sync", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	return nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if err := f.check("truncate", os.O_WRONLY); err != nil {
+		return err
+	}
+
+	if size < 0 {
+		return &fs.PathError{Op: "truncate", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	f.data.locker.Lock()
+	defer f.data.locker.Unlock()
+
+	if size <= int64(len(f.data.data)) {
+		f.data.data = f.data.data[:size]
+	} else {
+		f.data.data = append(f.data.data, make([]byte, size-int64(len(f.data.data)))...)
+	}
+
+	f.data.modTime = time.Now()
+
+	return nil
+}
+
+// check returns error of closed handle or handle opened without access, os.O_RDONLY means read access.
+func (f *memFile) check(op string, access int) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	writable := f.flag&(os.O_WRONLY|os.O_RDWR) != 0
+	readable := f.flag&os.O_WRONLY == 0
+
+	if access == os.O_RDONLY && !readable || access == os.O_WRONLY && !writable {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrPermission}
+	}
+
+	return nil
+}
+
+func (d *memData) readAt(p []byte, off int64) (int, error) {
+	d.locker.RLock()
+	defer d.locker.RUnlock()
+
+	if off < 0 {
+		return 0, fs.ErrInvalid
+	}
+
+	if off >= int64(len(d.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, d.data[off:])
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (i *memFileInfo) Name() string {
+	return i.name
+}
+
+func (i *memFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i *memFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+
+	return 0644
+}
+
+func (i *memFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i *memFileInfo) IsDir() bool {
+	return i.dir
+}
+
+func (i *memFileInfo) Sys() interface{} {
+	return nil
+}
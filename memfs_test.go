@@ -0,0 +1,179 @@
+package eventstorage
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_NewMemory(t *testing.T) {
+	storage, err := NewMemory(WithFormat(FormatFramed), WithChecksums())
+
+	if err != nil {
+		t.Fatalf("NewMemory expect no error, got %v", err)
+	}
+
+	t.Cleanup(storage.Shutdown)
+	storage.SetAutoFlushCount(2)
+
+	for i, event := range []string{"first", "second", "third", "fourth", "fifth"} {
+		if offset, err := storage.Write([]byte(event)); err != nil || offset != i {
+			t.Fatalf("Write expect offset %d, got %d, err: %v", i, offset, err)
+		}
+	}
+
+	if events, _ := storage.Read(5, 0); len(events) != 4 {
+		t.Errorf("Read expect 4 auto flushed events, got %v", events)
+	}
+
+	storage.SetWriteFileMaxSize(1)
+
+	if _, err = storage.Write([]byte("sixth")); err != nil {
+		t.Fatalf("Write expect flush and rotation, got %v", err)
+	}
+
+	events := make([]string, 3)
+
+	if saved, err := storage.ReadTo(3, 2, events); err != nil || saved != 3 || events[0] != "third" || events[2] != "fifth" {
+		t.Errorf("ReadTo expect events from third, got %v, err: %v", events[:saved], err)
+	}
+
+	if files := storage.Files(); len(files) != 2 || files[0].Count != 6 {
+		t.Errorf("Files expect rotation by max size, got %+v", files)
+	}
+}
+
+func Test_MemFS_reopen(t *testing.T) {
+	fsys := NewMemFS()
+	_ = fsys.MkdirAll("events", 0755)
+	storage, _ := New("events", WithFS(fsys))
+
+	if _, err := New("events", WithFS(fsys)); err != ErrStorageLocked {
+		t.Errorf("New expect %v for the second writer, got %v", ErrStorageLocked, err)
+	}
+
+	_, _ = storage.Write([]byte("kept"))
+	_, _ = storage.Flush()
+	_, _ = storage.Write([]byte("not flushed"))
+	storage.Shutdown()
+
+	if _, err := os.Stat("events"); !os.IsNotExist(err) {
+		t.Errorf("MemFS expect nothing on disk, got %v", err)
+	}
+
+	storage, err := New("events", WithFS(fsys))
+
+	if err != nil {
+		t.Fatalf("New expect reopened storage, got %v", err)
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if events, err := storage.Read(2, 0); err != nil || len(events) != 1 || events[0] != "kept" {
+		t.Errorf("Read expect flushed event only, got %v, err: %v", events, err)
+	}
+
+	readOnly, err := OpenReadOnly("events", WithFS(fsys))
+
+	if err != nil {
+		t.Fatalf("OpenReadOnly expect no error, got %v", err)
+	}
+
+	t.Cleanup(readOnly.Shutdown)
+
+	if events, _ := readOnly.Read(1, 0); len(events) != 1 || events[0] != "kept" {
+		t.Errorf("OpenReadOnly expect flushed event, got %v", events)
+	}
+}
+
+func Test_MemFS_compressionAndRetention(t *testing.T) {
+	storage, _ := NewMemory()
+	t.Cleanup(storage.Shutdown)
+	storage.SetWriteFileMaxSize(1)
+	storage.SetCompression(CompressionGzip)
+
+	for _, event := range []string{"a", "b", "c", "d"} {
+		_, _ = storage.Write([]byte(event))
+	}
+
+	storage.write.compressing.Wait()
+
+	if err := storage.SetRetention(Retention{MaxFiles: 3}); err != nil {
+		t.Fatalf("SetRetention expect no error, got %v", err)
+	}
+
+	files := storage.Files()
+
+	if len(files) != 3 || !files[0].Compressed || files[0].FirstOffset != 2 {
+		t.Errorf("Files expect 3 files from offset 2 with compressed first, got %+v", files)
+	}
+
+	if events, err := storage.Read(2, 2); err != nil || len(events) != 2 || events[0] != "c" || events[1] != "d" {
+		t.Errorf("Read expect c, d, got %v, err: %v", events, err)
+	}
+}
+
+func Test_MemFS_files(t *testing.T) {
+	fsys := NewMemFS()
+
+	if _, err := fsys.OpenFile("dir/file", os.O_CREATE|os.O_WRONLY, 0644); !os.IsNotExist(err) {
+		t.Errorf("OpenFile expect not exist error without directory, got %v", err)
+	}
+
+	_ = fsys.MkdirAll("dir/sub", 0755)
+	file, _ := fsys.OpenFile("dir/file", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	reader, _ := fsys.Open("dir/file")
+	_, _ = file.Write([]byte("hello"))
+	_, _ = file.Write([]byte(" world"))
+
+	if err := fsys.Rename("dir/file", "dir/renamed"); err != nil {
+		t.Fatalf("Rename expect no error, got %v", err)
+	}
+
+	buf := make([]byte, 5)
+
+	if n, err := reader.ReadAt(buf, 6); err != nil || string(buf[:n]) != "world" {
+		t.Errorf("ReadAt expect renamed file data, got %q, err: %v", buf[:n], err)
+	}
+
+	if data, err := io.ReadAll(reader); err != nil || string(data) != "hello world" {
+		t.Errorf("Read expect whole file, got %q, err: %v", data, err)
+	}
+
+	if _, err := reader.Write([]byte("x")); err == nil {
+		t.Errorf("Write expect error for read only file")
+	}
+
+	if err := file.Truncate(5); err != nil {
+		t.Errorf("Truncate expect no error, got %v", err)
+	}
+
+	if info, err := fsys.Stat("dir/renamed"); err != nil || info.Size() != 5 || info.IsDir() || time.Since(info.ModTime()) > time.Minute {
+		t.Errorf("Stat expect truncated file, got %+v, err: %v", info, err)
+	}
+
+	entries, _ := fsys.ReadDir("dir")
+
+	if len(entries) != 2 || entries[0].Name() != "renamed" || entries[1].Name() != "sub" || !entries[1].IsDir() {
+		t.Errorf("ReadDir expect renamed file and sub directory, got %v", entries)
+	}
+
+	if err := fsys.Remove("dir"); err == nil {
+		t.Errorf("Remove expect error for not empty directory")
+	}
+
+	_ = file.Close()
+
+	if _, err := file.Write([]byte("x")); err == nil {
+		t.Errorf("Write expect error for closed file")
+	}
+
+	if err := fsys.RemoveAll("dir"); err != nil {
+		t.Errorf("RemoveAll expect no error, got %v", err)
+	}
+
+	if _, err := fsys.Stat("dir/sub"); !os.IsNotExist(err) {
+		t.Errorf("Stat expect removed directory, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package eventstorage
+
+// Option configures EventStorage on creation.
+type Option func(s *EventStorage)
+
+// WithFormat sets events format for a new storage.
+// Existing storage keeps format saved in its registry.
+func WithFormat(format Format) Option {
+	return func(s *EventStorage) {
+		s.codec.format = format
+	}
+}
+
+// WithChecksums enables crc32 checksum of every event in a new storage,
+// broken events are reported by CorruptionError on read.
+// Existing storage keeps checksums setting saved in its registry.
+func WithChecksums() Option {
+	return func(s *EventStorage) {
+		s.codec.checksums = true
+	}
+}
+
+// WithTimestamps saves write time with every event of a new storage, it requires framed format.
+// Existing storage keeps timestamps setting saved in its registry.
+func WithTimestamps() Option {
+	return func(s *EventStorage) {
+		s.codec.timestamps = true
+	}
+}
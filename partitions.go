@@ -0,0 +1,256 @@
+package eventstorage
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Partitioned spreads events by key between independent EventStorage partitions, every partition
+// has its own files and locks, so writes of different partitions go in parallel.
+// Events of one key always go to the same partition, so they keep write order.
+type Partitioned struct {
+	basePath   string
+	fsys       FS // File system of partitions, set by options.
+	partitions []*EventStorage
+}
+
+// PartitionEvent is an event with its partition.
+type PartitionEvent struct {
+	Partition int
+	Event
+}
+
+// NewPartitioned opens partitioned storage in base path, options are applied to every partition.
+// Count of partitions is saved on creation, opening with another count returns ErrPartitionsCount.
+func NewPartitioned(basePath string, count int, options ...Option) (*Partitioned, error) {
+	if count <= 0 {
+		return nil, ErrPartitionsCount
+	}
+
+	p := &Partitioned{basePath: basePath, fsys: optionsFS(options)}
+
+	if err := p.fsys.MkdirAll(basePath, 0755); err != nil {
+		return nil, errors.New("Failed to create base path: " + err.Error())
+	}
+
+	if err := p.checkCount(count); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < count; i++ {
+		path := p.getFilePath(fmt.Sprintf(partitionDirTemplate, i))
+
+		if err := p.fsys.MkdirAll(path, 0755); err != nil {
+			p.Shutdown()
+			return nil, errors.New("Failed to create partition: " + err.Error())
+		}
+
+		s, err := New(path, options...)
+
+		if err != nil {
+			p.Shutdown()
+			return nil, err
+		}
+
+		p.partitions = append(p.partitions, s)
+	}
+
+	return p, nil
+}
+
+// WriteKey appends event into partition of key and returns the partition and event offset in it.
+func (p *Partitioned) WriteKey(key []byte, data []byte) (partition int, offset int, err error) {
+	partition = p.PartitionFor(key)
+	offset, err = p.partitions[partition].Write(data)
+
+	return partition, offset, err
+}
+
+// PartitionFor returns partition of key.
+func (p *Partitioned) PartitionFor(key []byte) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write(key)
+
+	return int(hash.Sum32() % uint32(len(p.partitions)))
+}
+
+// Partition returns storage of partition for reads, cursors and subscriptions.
+func (p *Partitioned) Partition(partition int) *EventStorage {
+	return p.partitions[partition]
+}
+
+// Partitions returns count of partitions.
+func (p *Partitioned) Partitions() int {
+	return len(p.partitions)
+}
+
+// Flush flushes all partitions, it returns the first error.
+func (p *Partitioned) Flush() (err error) {
+	for _, s := range p.partitions {
+		if _, flushErr := s.Flush(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+	}
+
+	return err
+}
+
+// Shutdown shuts down all partitions.
+func (p *Partitioned) Shutdown() {
+	for _, s := range p.partitions {
+		s.Shutdown()
+	}
+}
+
+// ReadMerged reads up to count events of all partitions starting from offsets, one offset per partition.
+// Events are merged by write time with timestamps enabled, otherwise partitions are taken in turn.
+func (p *Partitioned) ReadMerged(offsets []int, count int) ([]PartitionEvent, error) {
+	cursor, err := p.MergedCursor(offsets)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close()
+
+	events := make([]PartitionEvent, 0, count)
+
+	for len(events) < count {
+		event, err := cursor.Next()
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return events, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// MergedCursor reads events of all partitions one by one, merged like ReadMerged.
+// MergedCursor is not safe for concurrent use.
+type MergedCursor struct {
+	cursors []*Cursor
+	heads   []*PartitionEvent // The next event of every partition, nil if it is not read yet.
+	turn    int               // Partition, which is preferred for events with the same write time.
+}
+
+// MergedCursor returns cursor, which starts from offsets, one offset per partition.
+func (p *Partitioned) MergedCursor(offsets []int) (*MergedCursor, error) {
+	if len(offsets) != len(p.partitions) {
+		return nil, ErrPartitionsCount
+	}
+
+	c := &MergedCursor{
+		cursors: make([]*Cursor, len(p.partitions)),
+		heads:   make([]*PartitionEvent, len(p.partitions)),
+	}
+
+	for i, s := range p.partitions {
+		c.cursors[i] = s.Cursor(offsets[i])
+	}
+
+	return c, nil
+}
+
+// Next returns the next event of all partitions, io.EOF means there are no more flushed events yet
+// and Next may be called again later.
+func (c *MergedCursor) Next() (PartitionEvent, error) {
+	next := -1
+
+	for n := range c.cursors {
+		i := (c.turn + n) % len(c.cursors)
+
+		if c.heads[i] == nil {
+			offset := c.cursors[i].Offset()
+			data, err := c.cursors[i].Next()
+
+			if err == io.EOF {
+				continue
+			} else if err != nil {
+				return PartitionEvent{}, err
+			}
+
+			c.heads[i] = &PartitionEvent{Partition: i, Event: Event{Offset: offset, Data: data, Time: c.cursors[i].Time()}}
+		}
+
+		if next < 0 || c.heads[i].Time.Before(c.heads[next].Time) {
+			next = i
+		}
+	}
+
+	if next < 0 {
+		return PartitionEvent{}, io.EOF
+	}
+
+	event := *c.heads[next]
+	c.heads[next] = nil
+	c.turn = (next + 1) % len(c.cursors)
+
+	return event, nil
+}
+
+// Offsets returns offsets of events, which are not returned by Next yet, one offset per partition.
+// They may be used to continue reading by a new cursor.
+func (c *MergedCursor) Offsets() []int {
+	offsets := make([]int, len(c.cursors))
+
+	for i, cursor := range c.cursors {
+		if c.heads[i] != nil {
+			offsets[i] = c.heads[i].Offset
+		} else {
+			offsets[i] = cursor.Offset()
+		}
+	}
+
+	return offsets
+}
+
+// Close releases file handles of all partitions cursors.
+func (c *MergedCursor) Close() error {
+	var err error
+
+	for _, cursor := range c.cursors {
+		if closeErr := cursor.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// checkCount saves count of partitions for a new storage and compares it with saved one for existing storage.
+func (p *Partitioned) checkCount(count int) error {
+	path := p.getFilePath(partitionsFileName)
+	raw, err := readFSFile(p.fsys, path)
+
+	if os.IsNotExist(err) {
+		if err = writeFSFile(p.fsys, path, []byte(strconv.Itoa(count)+"\n")); err != nil {
+			return errors.New("Failed to save partitions count: " + err.Error())
+		}
+
+		return nil
+	} else if err != nil {
+		return errors.New("Failed to read partitions count: " + err.Error())
+	}
+
+	if saved, err := strconv.Atoi(strings.TrimSpace(string(raw))); err != nil {
+		return errors.New("Failed to read partitions count: " + err.Error())
+	} else if saved != count {
+		return ErrPartitionsCount
+	}
+
+	return nil
+}
+
+func (p *Partitioned) getFilePath(fileName string) string {
+	return p.basePath + string(os.PathSeparator) + fileName
+}
@@ -0,0 +1,86 @@
+package eventstorage
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func Test_Partitioned_WriteKey(t *testing.T) {
+	path := t.TempDir()
+	storage, err := NewPartitioned(path, 4, WithFormat(FormatFramed), WithTimestamps())
+
+	if err != nil {
+		t.Fatalf("Partitioned failed to open, err: %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for k := 0; k < 8; k++ {
+		wg.Add(1)
+
+		go func(key string) {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				_, _, _ = storage.WriteKey([]byte(key), []byte(key+" "+strconv.Itoa(i)))
+			}
+		}("key" + strconv.Itoa(k))
+	}
+
+	wg.Wait()
+	_ = storage.Flush()
+
+	events, err := storage.ReadMerged(make([]int, 4), 1000)
+
+	if err != nil || len(events) != 800 {
+		t.Fatalf("Partitioned ReadMerged expect 800 events, got %v, err: %v", len(events), err)
+	}
+
+	next := make(map[string]int)
+
+	for i, event := range events {
+		key := string(event.Data[:4])
+
+		if string(event.Data) != key+" "+strconv.Itoa(next[key]) || event.Partition != storage.PartitionFor([]byte(key)) {
+			t.Fatalf("Partitioned expect %v %v in partition %v, got %q in %v", key, next[key], storage.PartitionFor([]byte(key)), event.Data, event.Partition)
+		}
+
+		if i > 0 && event.Time.Before(events[i-1].Time) {
+			t.Fatalf("Partitioned ReadMerged expect events ordered by time at %v", i)
+		}
+
+		next[key]++
+	}
+
+	storage.Shutdown()
+
+	if _, err = NewPartitioned(path, 8); err != ErrPartitionsCount {
+		t.Errorf("Partitioned expect %v on reopen with another count, got %v", ErrPartitionsCount, err)
+	}
+}
+
+func Test_MergedCursor_TakesPartitionsInTurn(t *testing.T) {
+	storage, _ := NewPartitioned(t.TempDir(), 2)
+	t.Cleanup(storage.Shutdown)
+
+	for i := 0; i < 3; i++ {
+		_, _ = storage.Partition(0).Write([]byte("a" + strconv.Itoa(i)))
+		_, _ = storage.Partition(1).Write([]byte("b" + strconv.Itoa(i)))
+	}
+
+	_ = storage.Flush()
+	cursor, _ := storage.MergedCursor([]int{1, 0})
+	t.Cleanup(func() { _ = cursor.Close() })
+	expected := []string{"a1", "b0", "a2", "b1", "b2"}
+
+	for _, data := range expected {
+		if event, err := cursor.Next(); err != nil || string(event.Data) != data {
+			t.Fatalf("MergedCursor expect %v, got %q, err: %v", data, event.Data, err)
+		}
+	}
+
+	if offsets := cursor.Offsets(); offsets[0] != 3 || offsets[1] != 3 {
+		t.Errorf("MergedCursor expect offsets [3 3], got %v", offsets)
+	}
+}
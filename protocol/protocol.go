@@ -0,0 +1,252 @@
+// Package protocol is binary TCP protocol of events storage server.
+//
+// Every request and response is a frame: payload length as uint32 and payload. Integers are little-endian.
+// Request payload is command byte and command arguments. Response payload is status byte and result,
+// or error message for not ok status. Responses go in order of requests, so requests may be pipelined.
+//
+//	Write      data                                  -> offset int64
+//	Read       offset int64, count uint32            -> count uint32, count times data length uint32 and data
+//	Flush                                            -> count int64
+//	Subscribe  offset int64, buffer uint32           -> stream of responses: offset int64, time int64, data
+//	State                                            -> replica state, see AppendReplicaState
+//	ReadFile   offset int64, size uint32, name       -> up to size bytes of events file, less at its end
+//
+// Subscribe turns connection into stream of events, which ends with error response.
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/pankif/eventstorage"
+)
+
+const (
+	CommandWrite byte = iota + 1
+	CommandRead
+	CommandFlush
+	CommandSubscribe
+	CommandState
+	CommandReadFile
+)
+
+const (
+	StatusOK byte = iota
+	StatusError
+	StatusOffsetRemoved
+	StatusShutdown
+	StatusReadOnly
+	StatusSubscriberTooSlow
+	StatusFileNotKept
+)
+
+const (
+	frameHeaderSize       = 4
+	MaxFrameSize          = 64 << 20 // Max size of frame payload.
+	ReadArgsSize          = 12       // Size of Read arguments.
+	SubscribeArgsSize     = 12       // Size of Subscribe arguments.
+	SubscribeEventMinSize = 17       // Size of Subscribe event response without data.
+	ReadFileArgsMinSize   = 12       // Size of ReadFile arguments without file name.
+)
+
+var (
+	ErrFrameTooLarge  = errors.New("frame too large")
+	ErrMalformedFrame = errors.New("malformed frame")
+	ErrUnknownCommand = errors.New("unknown command")
+	statusErrors      = map[byte]error{
+		StatusOffsetRemoved:     eventstorage.ErrOffsetRemoved,
+		StatusShutdown:          eventstorage.ErrStorageShutdown,
+		StatusReadOnly:          eventstorage.ErrReadOnly,
+		StatusSubscriberTooSlow: eventstorage.ErrSubscriberTooSlow,
+		StatusFileNotKept:       eventstorage.ErrFileNotKept,
+	}
+)
+
+// WriteFrame writes payload with its length.
+func WriteFrame(w io.Writer, payload []byte) error {
+	if len(payload) > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	var header [frameHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(payload)))
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(payload)
+
+	return err
+}
+
+// ReadFrame reads frame payload into buf, which grows when payload does not fit.
+func ReadFrame(r io.Reader, buf []byte) ([]byte, error) {
+	var header [frameHeaderSize]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[:])
+
+	if size > MaxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+
+	if cap(buf) < int(size) {
+		buf = make([]byte, size)
+	}
+
+	buf = buf[:size]
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// AppendInt64 appends little-endian int64.
+func AppendInt64(buf []byte, value int64) []byte {
+	var raw [8]byte
+	binary.LittleEndian.PutUint64(raw[:], uint64(value))
+
+	return append(buf, raw[:]...)
+}
+
+// AppendUint32 appends little-endian uint32.
+func AppendUint32(buf []byte, value uint32) []byte {
+	var raw [4]byte
+	binary.LittleEndian.PutUint32(raw[:], value)
+
+	return append(buf, raw[:]...)
+}
+
+// ErrorResponse returns response payload of error, storage errors keep their own status.
+func ErrorResponse(err error) []byte {
+	for status, statusErr := range statusErrors {
+		if errors.Is(err, statusErr) {
+			return []byte{status}
+		}
+	}
+
+	return append([]byte{StatusError}, err.Error()...)
+}
+
+// ResponseError returns error of response payload, nil for ok status.
+func ResponseError(payload []byte) error {
+	if len(payload) == 0 {
+		return ErrMalformedFrame
+	}
+
+	if payload[0] == StatusOK {
+		return nil
+	}
+
+	if err, ok := statusErrors[payload[0]]; ok {
+		return err
+	}
+
+	return errors.New(string(payload[1:]))
+}
+
+// AppendReplicaState appends registry length and registry, files, index files, consumers length and consumers.
+// Files are files count and for every file name length, name, size, first offset and count.
+func AppendReplicaState(buf []byte, state eventstorage.ReplicaState) []byte {
+	buf = append(AppendUint32(buf, uint32(len(state.Registry))), state.Registry...)
+	buf = appendReplicaFiles(buf, state.Files)
+	buf = appendReplicaFiles(buf, state.Indexes)
+
+	return append(AppendUint32(buf, uint32(len(state.Consumers))), state.Consumers...)
+}
+
+func appendReplicaFiles(buf []byte, files []eventstorage.ReplicaFile) []byte {
+	buf = AppendUint32(buf, uint32(len(files)))
+
+	for _, file := range files {
+		buf = append(AppendUint32(buf, uint32(len(file.Name))), file.Name...)
+		buf = AppendInt64(buf, file.Size)
+		buf = AppendInt64(buf, int64(file.FirstOffset))
+		buf = AppendInt64(buf, int64(file.Count))
+	}
+
+	return buf
+}
+
+// ParseReplicaState parses replica state appended by AppendReplicaState.
+func ParseReplicaState(buf []byte) (state eventstorage.ReplicaState, err error) {
+	var raw []byte
+
+	if raw, buf, err = takeBytes(buf); err != nil {
+		return state, err
+	}
+
+	state.Registry = append([]byte(nil), raw...)
+
+	if state.Files, buf, err = parseReplicaFiles(buf); err != nil {
+		return state, err
+	}
+
+	if state.Indexes, buf, err = parseReplicaFiles(buf); err != nil {
+		return state, err
+	}
+
+	if raw, _, err = takeBytes(buf); err != nil {
+		return state, err
+	}
+
+	if len(raw) > 0 {
+		state.Consumers = append([]byte(nil), raw...)
+	}
+
+	return state, nil
+}
+
+// parseReplicaFiles returns files appended by appendReplicaFiles and the rest of buf.
+func parseReplicaFiles(buf []byte) (files []eventstorage.ReplicaFile, rest []byte, err error) {
+	var raw []byte
+
+	if len(buf) < 4 {
+		return nil, nil, ErrMalformedFrame
+	}
+
+	count := int(binary.LittleEndian.Uint32(buf))
+	buf = buf[4:]
+
+	for i := 0; i < count; i++ {
+		if raw, buf, err = takeBytes(buf); err != nil {
+			return nil, nil, err
+		}
+
+		if len(buf) < 24 {
+			return nil, nil, ErrMalformedFrame
+		}
+
+		files = append(files, eventstorage.ReplicaFile{
+			Name:        string(raw),
+			Size:        int64(binary.LittleEndian.Uint64(buf)),
+			FirstOffset: int(binary.LittleEndian.Uint64(buf[8:])),
+			Count:       int(binary.LittleEndian.Uint64(buf[16:])),
+		})
+		buf = buf[24:]
+	}
+
+	return files, buf, nil
+}
+
+// takeBytes returns bytes prefixed by their length and the rest of buf.
+func takeBytes(buf []byte) ([]byte, []byte, error) {
+	if len(buf) < 4 || len(buf)-4 < int(binary.LittleEndian.Uint32(buf)) {
+		return nil, nil, ErrMalformedFrame
+	}
+
+	size := 4 + int(binary.LittleEndian.Uint32(buf))
+
+	return buf[4:size], buf[size:], nil
+}
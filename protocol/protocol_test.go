@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pankif/eventstorage"
+)
+
+func Test_Frame(t *testing.T) {
+	buf := new(bytes.Buffer)
+	_ = WriteFrame(buf, []byte("first"))
+	_ = WriteFrame(buf, nil)
+
+	for _, expected := range []string{"first", ""} {
+		if payload, err := ReadFrame(buf, nil); err != nil || string(payload) != expected {
+			t.Errorf("ReadFrame expect %q, got %q, err: %v", expected, payload, err)
+		}
+	}
+
+	buf.Write([]byte{10, 0, 0, 0, 'a'})
+
+	if _, err := ReadFrame(buf, nil); err == nil {
+		t.Errorf("ReadFrame expect error of incomplete frame")
+	}
+
+	buf.Reset()
+	buf.Write([]byte{255, 255, 255, 255})
+
+	if _, err := ReadFrame(buf, nil); err != ErrFrameTooLarge {
+		t.Errorf("ReadFrame expect %v, got %v", ErrFrameTooLarge, err)
+	}
+}
+
+func Test_ErrorResponse(t *testing.T) {
+	for _, err := range []error{eventstorage.ErrOffsetRemoved, eventstorage.ErrStorageShutdown, errors.New("failure")} {
+		if got := ResponseError(ErrorResponse(err)); got.Error() != err.Error() {
+			t.Errorf("ResponseError expect %v, got %v", err, got)
+		}
+	}
+
+	if err := ResponseError([]byte{StatusOK}); err != nil {
+		t.Errorf("ResponseError expect nil for ok status, got %v", err)
+	}
+}
+
+func Test_ReplicaState(t *testing.T) {
+	state := eventstorage.ReplicaState{
+		Registry:  []byte("events.1\n"),
+		Files:     []eventstorage.ReplicaFile{{Name: "events.1", Size: 10, FirstOffset: 5, Count: 2}},
+		Indexes:   []eventstorage.ReplicaFile{{Name: "events.1.idx", Size: 8, FirstOffset: 5}},
+		Consumers: []byte("consumer 6\n"),
+	}
+
+	parsed, err := ParseReplicaState(AppendReplicaState(nil, state))
+
+	if err != nil || !reflect.DeepEqual(parsed, state) {
+		t.Errorf("ParseReplicaState expect %+v, got %+v, err: %v", state, parsed, err)
+	}
+
+	if _, err = ParseReplicaState(AppendReplicaState(nil, state)[:20]); err != ErrMalformedFrame {
+		t.Errorf("ParseReplicaState expect %v for truncated state, got %v", ErrMalformedFrame, err)
+	}
+}
@@ -0,0 +1,176 @@
+package eventstorage
+
+import (
+	"bytes"
+	"errors"
+)
+
+// lockBasePath takes exclusive lock file of writer, so the second writer fails with ErrStorageLocked.
+// Lock is released by Shutdown or by exit of process.
+func (s *EventStorage) lockBasePath() error {
+	file, err := s.fsys.Lock(s.getFilePath(lockFileName))
+
+	if err == ErrStorageLocked {
+		return err
+	} else if err != nil {
+		return errors.New("Failed to lock base path: " + err.Error())
+	}
+
+	s.lockFile = file
+
+	return nil
+}
+
+// OpenReadOnly opens storage written by another process, it never creates or writes files.
+// Write methods return ErrReadOnly. Storage follows the writer's progress by Refresh,
+// reads and cursors see events flushed by writer before the last Refresh.
+// Settings of options are taken from registry, so only WithFS matters.
+func OpenReadOnly(basePath string, options ...Option) (*EventStorage, error) {
+	s := &EventStorage{
+		basePath:  basePath,
+		fsys:      optionsFS(options),
+		write:     &write{buf: new(bytes.Buffer)},
+		readOnly:  true,
+		turnedOff: make(chan bool, 1),
+		flushed:   make(chan struct{}),
+	}
+
+	content, err := s.readRegistryFile()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.codec = content.settings
+	s.read = &read{reader: newRecordReader(make([]byte, readBufLimit), s.codec)}
+
+	if err = s.refreshFiles(content); err != nil {
+		return nil, err
+	}
+
+	if err = s.loadConsumers(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Refresh catches up read-only storage with writer: new and grown events files, files removed
+// by retention or compressed, and committed consumers offsets. Subscriptions are woken up by Refresh.
+// It does nothing for writer storage, which is always up to date.
+func (s *EventStorage) Refresh() error {
+	if !s.readOnly {
+		return nil
+	}
+
+	s.read.locker.Lock()
+	defer s.read.locker.Unlock()
+
+	content, err := s.readRegistryFile()
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.refreshFiles(content); err != nil {
+		return err
+	}
+
+	s.consumersLocker.Lock()
+	defer s.consumersLocker.Unlock()
+
+	return s.loadConsumers()
+}
+
+// readRegistryFile reads registry without keeping it open, writer replaces it on retention and compression.
+func (s *EventStorage) readRegistryFile() (registryContent, error) {
+	raw, err := s.readFile(s.getFilePath(registryFileName))
+
+	if err != nil {
+		return registryContent{}, errors.New("Failed to read files registry: " + err.Error())
+	}
+
+	content, err := readRegistry(bytes.NewReader(raw))
+
+	if err == nil && len(content.files) == 0 {
+		err = ErrNoEventsFiles
+	}
+
+	return content, err
+}
+
+// refreshFiles replaces events files by registry ones, it reuses sealed files, which did not change,
+// and rescans the rest. It must be called under read locker.
+func (s *EventStorage) refreshFiles(content registryContent) error {
+	known := make(map[int]*eventsFile, len(s.files))
+
+	for _, file := range s.files {
+		known[file.number] = file
+	}
+
+	lastNumber := s.lastFileNumber()
+	files := make([]*eventsFile, 0, len(content.files))
+	reused := make(map[File]bool, len(content.files))
+
+	for i, file := range content.files {
+		old := known[file.number]
+
+		if old != nil && old.compressed == file.compressed {
+			file.reader = old.reader
+			reused[old.reader] = true
+
+			if old.compressed || old.number < lastNumber {
+				sealed := *old
+				files = append(files, &sealed)
+				continue
+			}
+		} else {
+			reader, err := s.fsys.Open(s.getFilePath(s.getEventsFileName(file)))
+
+			if err != nil {
+				closeNotReused(files, reused)
+				return errors.New("Failed to open events file to read: " + err.Error())
+			}
+
+			file.reader = reader
+		}
+
+		if err := s.loadIndex(file, i == len(content.files)-1); err != nil {
+			closeNotReused(append(files, file), reused)
+			return err
+		}
+
+		files = append(files, file)
+	}
+
+	firstOffset := content.baseOffset
+
+	for _, file := range files {
+		file.firstOffset = firstOffset
+		firstOffset += file.count
+	}
+
+	s.filesLocker.Lock()
+	previous := s.files
+	s.files = files
+	s.baseOffset = content.baseOffset
+	s.notifyFlushed()
+	s.filesLocker.Unlock()
+
+	for _, file := range previous {
+		if !reused[file.reader] {
+			_ = file.reader.Close()
+		}
+	}
+
+	return nil
+}
+
+// closeNotReused closes events files opened by failed refresh.
+func closeNotReused(files []*eventsFile, reused map[File]bool) {
+	for _, file := range files {
+		if !reused[file.reader] {
+			_ = file.reader.Close()
+		}
+	}
+}
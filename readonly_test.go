@@ -0,0 +1,130 @@
+package eventstorage
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func Test_eventStorage_SecondWriterLocked(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+
+	if _, err := New(path); err != ErrStorageLocked {
+		t.Errorf("SecondWriterLocked expect %v, got %v", ErrStorageLocked, err)
+	}
+
+	storage.Shutdown()
+	storage, err := New(path)
+
+	if err != nil {
+		t.Fatalf("SecondWriterLocked expect lock released by Shutdown, err: %v", err)
+	}
+
+	storage.Shutdown()
+}
+
+func Test_OpenReadOnly_FollowsWriter(t *testing.T) {
+	path := t.TempDir()
+	writer, _ := New(path)
+	t.Cleanup(writer.Shutdown)
+	writer.SetWriteFileMaxSize(50)
+
+	for i := 0; i < 10; i++ {
+		_, _ = writer.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = writer.Flush()
+
+	reader, err := OpenReadOnly(path)
+
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed, err: %v", err)
+	}
+
+	t.Cleanup(reader.Shutdown)
+
+	if events, err := reader.Read(20, 0); err != nil || len(events) != 10 {
+		t.Errorf("OpenReadOnly expect 10 events, got %q, err: %v", events, err)
+	}
+
+	for i := 10; i < 20; i++ {
+		_, _ = writer.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = writer.Flush()
+	_ = writer.Commit("billing", 15)
+	writer.SetCompression(CompressionGzip)
+	writer.write.compressing.Wait()
+	_ = writer.SetRetention(Retention{MaxFiles: 2})
+
+	if err = reader.Refresh(); err != nil {
+		t.Fatalf("OpenReadOnly failed to refresh, err: %v", err)
+	}
+
+	events, err := reader.Read(20, writer.baseOffset)
+
+	if err != nil || len(events) != 20-writer.baseOffset || events[len(events)-1] != "event 19" {
+		t.Errorf("OpenReadOnly expect events from %v after refresh, got %q, err: %v", writer.baseOffset, events, err)
+	}
+
+	if _, err = reader.Read(1, 0); err != ErrOffsetRemoved {
+		t.Errorf("OpenReadOnly expect %v after refresh, got %v", ErrOffsetRemoved, err)
+	}
+
+	if reader.Committed("billing") != 15 {
+		t.Errorf("OpenReadOnly expect committed offset 15, got %v", reader.Committed("billing"))
+	}
+}
+
+func Test_OpenReadOnly_NeverWrites(t *testing.T) {
+	path := t.TempDir()
+	writer, _ := New(path, WithFormat(FormatFramed))
+	_, _ = writer.Write([]byte("complete"))
+	_, _ = writer.Flush()
+	writer.Shutdown()
+
+	// Writer is in the middle of flush.
+	file, _ := os.OpenFile(writer.getFilePath(writer.getFileName(1)), os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = file.Write([]byte{100, 0, 0, 0, 'i', 'n'})
+	_ = file.Close()
+	_ = os.Remove(writer.getIndexPath(1))
+	before, _ := os.ReadDir(path)
+
+	reader, err := OpenReadOnly(path)
+
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed, err: %v", err)
+	}
+
+	events, err := reader.Read(2, 0)
+
+	if err != nil || len(events) != 1 || events[0] != "complete" {
+		t.Errorf("OpenReadOnly expect complete event only, got %q, err: %v", events, err)
+	}
+
+	if _, err = reader.Write([]byte("event")); err != ErrReadOnly {
+		t.Errorf("OpenReadOnly expect Write %v, got %v", ErrReadOnly, err)
+	}
+
+	if err = reader.Commit("billing", 1); err != ErrReadOnly {
+		t.Errorf("OpenReadOnly expect Commit %v, got %v", ErrReadOnly, err)
+	}
+
+	reader.Shutdown()
+	after, _ := os.ReadDir(path)
+
+	if len(after) != len(before) {
+		t.Errorf("OpenReadOnly expect %v files, got %v", len(before), len(after))
+	}
+
+	if info, _ := os.Stat(writer.getFilePath(writer.getFileName(1))); info.Size() != writer.write.fileSize+6 {
+		t.Errorf("OpenReadOnly expect not truncated events file, got size %v", info.Size())
+	}
+}
+
+func Test_OpenReadOnly_WithoutRegistry(t *testing.T) {
+	if _, err := OpenReadOnly(t.TempDir()); err == nil {
+		t.Errorf("OpenReadOnly expect error without registry")
+	}
+}
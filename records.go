@@ -0,0 +1,418 @@
+package eventstorage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var formatNames = map[Format]string{
+	FormatLines:  "lines",
+	FormatFramed: "framed",
+}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+func parseFormat(name string) (Format, error) {
+	for format, formatName := range formatNames {
+		if formatName == name {
+			return format, nil
+		}
+	}
+
+	return 0, ErrUnknownFormat
+}
+
+// CorruptionError reports broken event in events file.
+type CorruptionError struct {
+	File     int   // Number of events file.
+	Position int64 // Position of broken event in events file.
+	Err      error // Reason of corruption.
+}
+
+func (e *CorruptionError) Error() string {
+	return fmt.Sprintf("events file %d corrupted at position %d: %v", e.File, e.Position, e.Err)
+}
+
+func (e *CorruptionError) Unwrap() error {
+	return e.Err
+}
+
+// codec describes layout of events in files.
+type codec struct {
+	format     Format // Events format.
+	checksums  bool   // Every event carries checksum of its data.
+	timestamps bool   // Every event carries its write time, framed format only.
+}
+
+// settings returns codec as registry settings lines.
+func (c codec) settings() string {
+	settings := string(registryHeader) + "format=" + c.format.String() + "\n"
+
+	if c.checksums {
+		settings += string(registryHeader) + "checksum=crc32\n"
+	}
+
+	if c.timestamps {
+		settings += string(registryHeader) + "timestamps=unixnano\n"
+	}
+
+	return settings
+}
+
+// parseSetting applies registry setting.
+func (c *codec) parseSetting(key string, value string) (err error) {
+	switch key {
+	case "format":
+		c.format, err = parseFormat(value)
+	case "checksum":
+		if value != "crc32" {
+			return errors.New("unknown checksum " + value)
+		}
+
+		c.checksums = true
+	case "timestamps":
+		if value != "unixnano" {
+			return errors.New("unknown timestamps " + value)
+		}
+
+		c.timestamps = true
+	}
+
+	return err
+}
+
+// headerSize returns size of framed event header.
+// With checksums header is length, checksum of length and checksum of data, so broken length is not taken for incomplete event.
+func (c codec) headerSize() int {
+	if c.checksums {
+		return frameHeaderSize + checksumSize*2
+	}
+
+	return frameHeaderSize
+}
+
+// append writes event with its write time in unix nanoseconds into buf and returns written length.
+// Write time is saved with timestamps enabled only. Continued framed event is followed by the next event of its batch.
+func (c codec) append(buf *bytes.Buffer, data []byte, writeTime int64, continued bool) int64 {
+	if c.format == FormatFramed {
+		var header [frameHeaderSize + checksumSize*2 + timestampSize]byte
+		headerSize := c.headerSize()
+		bodyStart := buf.Len() + headerSize
+		length := uint32(len(data))
+
+		if continued {
+			length |= frameBatchFlag
+		}
+
+		binary.LittleEndian.PutUint32(header[:], length)
+
+		if c.checksums {
+			binary.LittleEndian.PutUint32(header[frameHeaderSize:], crc32.ChecksumIEEE(header[:frameHeaderSize]))
+		}
+
+		buf.Write(header[:headerSize])
+
+		if c.timestamps {
+			binary.LittleEndian.PutUint64(header[headerSize:], uint64(writeTime))
+			buf.Write(header[headerSize : headerSize+timestampSize])
+		}
+
+		buf.Write(data)
+
+		if c.checksums {
+			binary.LittleEndian.PutUint32(buf.Bytes()[bodyStart-checksumSize:], crc32.ChecksumIEEE(buf.Bytes()[bodyStart:]))
+		}
+
+		return int64(buf.Len() - bodyStart + headerSize)
+	}
+
+	buf.Write(data)
+	writtenLen := int64(len(data) + 1)
+
+	if c.checksums {
+		var sum [checksumSize]byte
+		var sumHex [checksumSize * 2]byte
+		binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(data))
+		hex.Encode(sumHex[:], sum[:])
+		buf.Write(sumHex[:])
+		writtenLen += int64(len(sumHex))
+	}
+
+	buf.WriteByte(LineBreak)
+
+	return writtenLen
+}
+
+// validate returns ErrLineBreakInEvent for event of lines format with line break.
+func (c codec) validate(data []byte) error {
+	if c.format == FormatLines && bytes.IndexByte(data, LineBreak) >= 0 {
+		return ErrLineBreakInEvent
+	}
+
+	return nil
+}
+
+// size returns length of event in events file.
+func (c codec) size(data []byte) int64 {
+	if c.format == FormatFramed && c.timestamps {
+		return int64(c.headerSize() + timestampSize + len(data))
+	} else if c.format == FormatFramed {
+		return int64(c.headerSize() + len(data))
+	} else if c.checksums {
+		return int64(len(data) + checksumSize*2 + 1)
+	}
+
+	return int64(len(data) + 1)
+}
+
+// recordReader reads events one by one from events file data.
+type recordReader struct {
+	codec
+	number    int             // Number of events file, for corruption errors.
+	src       io.Reader       // Events data source.
+	buf       []byte          // For read data from source.
+	head      int             // Start of not consumed data in buf.
+	tail      int             // End of read data in buf.
+	record    []byte          // For collect event data, which does not fit in buf.
+	gzip      *gzip.Reader    // For read compressed events files.
+	time      int64           // Write time of the last event in unix nanoseconds, with timestamps enabled.
+	continued bool            // The last framed event is followed by the next event of its batch.
+	pos       int64           // Position of the next event in file.
+	end       int64           // Position of source end in file.
+	ctx       context.Context // Reading of source stops when context is done, if set.
+}
+
+func newRecordReader(buf []byte, c codec) *recordReader {
+	return &recordReader{buf: buf, codec: c}
+}
+
+func (r *recordReader) reset(number int, src io.Reader, pos int64, end int64) {
+	r.number = number
+	r.src = src
+	r.head = 0
+	r.tail = 0
+	r.pos = pos
+	r.end = end
+}
+
+// next returns next event data, it is valid until the next call.
+// Broken event is reported by CorruptionError, io.EOF means that there are no more events.
+func (r *recordReader) next() ([]byte, error) {
+	return r.read(true)
+}
+
+// nextRaw returns next event without checksum verification.
+func (r *recordReader) nextRaw() ([]byte, error) {
+	return r.read(false)
+}
+
+func (r *recordReader) read(verify bool) (record []byte, err error) {
+	pos := r.pos
+
+	if r.format == FormatFramed {
+		record, err = r.nextFramed(verify)
+	} else {
+		record, err = r.nextLine(verify)
+	}
+
+	if err == io.ErrUnexpectedEOF || err == ErrChecksumMismatch {
+		err = &CorruptionError{File: r.number, Position: pos, Err: err}
+	}
+
+	return record, err
+}
+
+func (r *recordReader) nextLine(verify bool) ([]byte, error) {
+	line, err := r.line()
+
+	if err != nil || !r.checksums {
+		return line, err
+	}
+
+	if len(line) < checksumSize*2 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	data := line[:len(line)-checksumSize*2]
+	var sum [checksumSize]byte
+
+	if !verify {
+		return data, nil
+	}
+
+	if _, err = hex.Decode(sum[:], line[len(data):]); err != nil || crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(sum[:]) {
+		return nil, ErrChecksumMismatch
+	}
+
+	return data, nil
+}
+
+func (r *recordReader) line() ([]byte, error) {
+	r.record = r.record[:0]
+
+	for {
+		if r.head == r.tail {
+			if err := r.fill(); err != nil {
+				if err == io.EOF && len(r.record) > 0 {
+					return nil, io.ErrUnexpectedEOF
+				}
+
+				return nil, err
+			}
+		}
+
+		chunk := r.buf[r.head:r.tail]
+
+		if i := bytes.IndexByte(chunk, LineBreak); i >= 0 {
+			r.head += i + 1
+
+			if len(r.record) > 0 {
+				r.record = append(r.record, chunk[:i]...)
+				r.pos += int64(len(r.record) + 1)
+				return r.record, nil
+			}
+
+			r.pos += int64(i + 1)
+			return chunk[:i], nil
+		}
+
+		r.record = append(r.record, chunk...)
+		r.head = r.tail
+	}
+}
+
+func (r *recordReader) nextFramed(verify bool) ([]byte, error) {
+	header, err := r.take(r.headerSize())
+
+	if err != nil {
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header)
+	bodyLen := int64(length & frameLengthMask)
+	r.continued = length&frameBatchFlag != 0
+	sum := uint32(0)
+
+	if r.checksums {
+		// Length is verified even without data verification, broken length must not look like incomplete event.
+		if crc32.ChecksumIEEE(header[:frameHeaderSize]) != binary.LittleEndian.Uint32(header[frameHeaderSize:]) {
+			return nil, ErrChecksumMismatch
+		}
+
+		sum = binary.LittleEndian.Uint32(header[frameHeaderSize+checksumSize:])
+	}
+
+	if r.timestamps {
+		bodyLen += timestampSize
+	}
+
+	headerSize := int64(len(header))
+
+	if r.pos+headerSize+bodyLen > r.end {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	body, err := r.take(int(bodyLen))
+
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	if verify && r.checksums && crc32.ChecksumIEEE(body) != sum {
+		return nil, ErrChecksumMismatch
+	}
+
+	r.pos += headerSize + bodyLen
+
+	if r.timestamps {
+		r.time = int64(binary.LittleEndian.Uint64(body))
+		return body[timestampSize:], nil
+	}
+
+	return body, nil
+}
+
+// take returns next n bytes of source, it collects them in record when they do not fit in buf.
+func (r *recordReader) take(n int) ([]byte, error) {
+	if r.tail-r.head >= n {
+		r.head += n
+		return r.buf[r.head-n : r.head], nil
+	}
+
+	r.record = r.record[:0]
+
+	for len(r.record) < n {
+		if r.head == r.tail {
+			if err := r.fill(); err != nil {
+				if err == io.EOF && len(r.record) > 0 {
+					return nil, io.ErrUnexpectedEOF
+				}
+
+				return nil, err
+			}
+		}
+
+		chunkLen := n - len(r.record)
+
+		if available := r.tail - r.head; available < chunkLen {
+			chunkLen = available
+		}
+
+		r.record = append(r.record, r.buf[r.head:r.head+chunkLen]...)
+		r.head += chunkLen
+	}
+
+	return r.record, nil
+}
+
+// committedErr converts end of data, while flushed events are expected, to corruption.
+func (r *recordReader) committedErr(err error) error {
+	if err == io.EOF {
+		return &CorruptionError{File: r.number, Position: r.pos, Err: io.ErrUnexpectedEOF}
+	}
+
+	return err
+}
+
+func (r *recordReader) skip(count int) error {
+	for i := 0; i < count; i++ {
+		if _, err := r.next(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *recordReader) fill() error {
+	if r.ctx != nil {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		readCount, err := r.src.Read(r.buf)
+
+		if readCount > 0 {
+			r.head = 0
+			r.tail = readCount
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package eventstorage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func Test_recordReader_next(t *testing.T) {
+	data := []byte("a\nlong event\n\nb")
+	reader := newRecordReader(make([]byte, 4), codec{format: FormatLines})
+	reader.reset(1, bytes.NewReader(data), 0, int64(len(data)))
+
+	for _, expected := range []string{"a", "long event", ""} {
+		record, err := reader.next()
+
+		if err != nil || string(record) != expected {
+			t.Errorf("recordReader next expect %q, got %q, err: %v", expected, record, err)
+			return
+		}
+	}
+
+	if reader.pos != 14 {
+		t.Errorf("recordReader expect position 14, got %v", reader.pos)
+	}
+
+	if _, err := reader.next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("recordReader expect unexpected EOF for incomplete event, got %v", err)
+	}
+}
+
+func Test_recordReader_nextFramed(t *testing.T) {
+	buf := new(bytes.Buffer)
+	events := []string{"a", "multi\nline\nevent", ""}
+
+	for _, event := range events {
+		codec{format: FormatFramed}.append(buf, []byte(event), 0, false)
+	}
+
+	data := append(buf.Bytes(), 10, 0, 0, 0, 'b')
+	reader := newRecordReader(make([]byte, 4), codec{format: FormatFramed})
+	reader.reset(1, bytes.NewReader(data), 0, int64(len(data)))
+
+	for _, expected := range events {
+		record, err := reader.next()
+
+		if err != nil || string(record) != expected {
+			t.Errorf("recordReader nextFramed expect %q, got %q, err: %v", expected, record, err)
+			return
+		}
+	}
+
+	if reader.pos != int64(buf.Len()) {
+		t.Errorf("recordReader expect position %v, got %v", buf.Len(), reader.pos)
+	}
+
+	if _, err := reader.next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("recordReader expect unexpected EOF for incomplete event, got %v", err)
+	}
+}
+
+func Test_eventStorage_FormatFramed(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path, WithFormat(FormatFramed))
+	event := "first line\nsecond line"
+
+	_, _ = storage.Write([]byte(event))
+	_, _ = storage.Write([]byte("next"))
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	storage, err := New(path)
+
+	if err != nil {
+		t.Errorf("FormatFramed failed to open, err: " + err.Error())
+		return
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if storage.codec.format != FormatFramed {
+		t.Errorf("FormatFramed expect format from registry, got %v", storage.codec.format)
+	}
+
+	if events, _ := storage.Read(2, 0); len(events) != 2 || events[0] != event || events[1] != "next" {
+		t.Errorf("FormatFramed read incorrect events %q", events)
+	}
+}
+
+func Test_eventStorage_FormatLinesWithoutRegistrySetting(t *testing.T) {
+	path := t.TempDir()
+	_ = os.WriteFile(path+string(os.PathSeparator)+registryFileName, []byte("events.1\n"), 0644)
+	_ = os.WriteFile(path+string(os.PathSeparator)+"events.1", []byte("old\nevents\n"), 0644)
+
+	storage, err := New(path, WithFormat(FormatFramed))
+
+	if err != nil {
+		t.Errorf("FormatLinesWithoutRegistrySetting failed to open, err: " + err.Error())
+		return
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if storage.codec.format != FormatLines {
+		t.Errorf("FormatLinesWithoutRegistrySetting expect lines format, got %v", storage.codec.format)
+	}
+
+	if events, _ := storage.Read(2, 0); len(events) != 2 || events[0] != "old" || events[1] != "events" {
+		t.Errorf("FormatLinesWithoutRegistrySetting read incorrect events %q", events)
+	}
+}
+
+func Test_eventStorage_UnknownFormat(t *testing.T) {
+	if _, err := New(t.TempDir(), WithFormat(Format(100))); err != ErrUnknownFormat {
+		t.Errorf("UnknownFormat expect error, got %v", err)
+	}
+}
+
+func Test_eventStorage_Checksums(t *testing.T) {
+	for _, format := range []Format{FormatLines, FormatFramed} {
+		path := t.TempDir()
+		storage, _ := New(path, WithFormat(format), WithChecksums())
+
+		_, _ = storage.Write([]byte("first"))
+		secondPos := storage.write.fileSize
+		_, _ = storage.Write([]byte("second"))
+		_, _ = storage.Flush()
+		storage.Shutdown()
+
+		storage, _ = New(path)
+
+		if !storage.codec.checksums {
+			t.Errorf("Checksums expect setting from registry for %v format", format)
+		}
+
+		if events, err := storage.Read(2, 0); err != nil || len(events) != 2 || events[1] != "second" {
+			t.Errorf("Checksums read incorrect events %q for %v format, err: %v", events, format, err)
+		}
+
+		storage.Shutdown()
+
+		// Corrupt checksum of the second event, empty event length is checksum end for both formats.
+		file, _ := os.OpenFile(storage.getFilePath(storage.getFileName(1)), os.O_WRONLY, 0644)
+		_, _ = file.WriteAt([]byte("S"), secondPos+int64(storage.codec.append(new(bytes.Buffer), nil, 0, false))-1)
+		_ = file.Close()
+
+		storage, _ = New(path)
+		events, err := storage.Read(2, 0)
+		storage.Shutdown()
+
+		var corruption *CorruptionError
+
+		if !errors.As(err, &corruption) || !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("Checksums expect corruption error for %v format, got %v", format, err)
+			continue
+		}
+
+		if corruption.File != 1 || corruption.Position != secondPos || len(events) != 1 {
+			t.Errorf("Checksums expect corruption of file 1 at %v, got %v", secondPos, corruption)
+		}
+	}
+}
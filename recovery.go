@@ -0,0 +1,40 @@
+package eventstorage
+
+import "errors"
+
+// Recovery describes events data dropped on storage open after crash.
+type Recovery struct {
+	File           int   // Number of events file with incomplete trailing event, 0 if nothing dropped.
+	TruncatedBytes int64 // Count of dropped bytes of incomplete event.
+}
+
+// Recovery returns report of crash recovery made by New.
+func (s *EventStorage) Recovery() Recovery {
+	return s.recovery
+}
+
+// truncateTornEvent drops incomplete trailing event of the last events file,
+// which left after crash in the middle of flush.
+func (s *EventStorage) truncateTornEvent(file *eventsFile, validSize int64) error {
+	if err := s.write.file.Truncate(validSize); err != nil {
+		return errors.New("failed to truncate incomplete event: " + err.Error())
+	}
+
+	s.recovery = Recovery{File: file.number, TruncatedBytes: file.size - validSize}
+	file.trim(validSize)
+
+	return nil
+}
+
+// trim drops file events and index entries after size.
+func (file *eventsFile) trim(size int64) {
+	file.size = size
+
+	for len(file.positions) > 0 && file.positions[len(file.positions)-1] >= size {
+		file.positions = file.positions[:len(file.positions)-1]
+
+		if file.times != nil {
+			file.times = file.times[:len(file.times)-1]
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package eventstorage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func Test_eventStorage_RecoveryTruncatesTornEvent(t *testing.T) {
+	tornEvents := map[Format][]byte{
+		FormatLines:  []byte("incomplete"),
+		FormatFramed: {100, 0, 0, 0, 'i', 'n'},
+	}
+
+	for format, torn := range tornEvents {
+		path := t.TempDir()
+		storage, _ := New(path, WithFormat(format))
+		_, _ = storage.Write([]byte("complete"))
+		_, _ = storage.Flush()
+		storage.Shutdown()
+
+		validSize := storage.write.fileSize
+		file, _ := os.OpenFile(storage.getFilePath(storage.getFileName(1)), os.O_APPEND|os.O_WRONLY, 0644)
+		_, _ = file.Write(torn)
+		_ = file.Close()
+
+		storage, err := New(path)
+
+		if err != nil {
+			t.Errorf("RecoveryTruncatesTornEvent failed to open %v format, err: %v", format, err)
+			continue
+		}
+
+		expected := Recovery{File: 1, TruncatedBytes: int64(len(torn))}
+
+		if storage.Recovery() != expected {
+			t.Errorf("RecoveryTruncatesTornEvent expect %+v for %v format, got %+v", expected, format, storage.Recovery())
+		}
+
+		if storage.calculateWriteFileSize() != validSize {
+			t.Errorf("RecoveryTruncatesTornEvent expect file size %v for %v format, got %v", validSize, format, storage.calculateWriteFileSize())
+		}
+
+		_, _ = storage.Write([]byte("next"))
+		_, _ = storage.Flush()
+		events, err := storage.Read(3, 0)
+		storage.Shutdown()
+
+		if err != nil || len(events) != 2 || events[0] != "complete" || events[1] != "next" {
+			t.Errorf("RecoveryTruncatesTornEvent read incorrect events %q for %v format, err: %v", events, format, err)
+		}
+	}
+}
+
+func Test_eventStorage_RecoveryNothingDropped(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	_, _ = storage.Write([]byte("complete"))
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	storage, _ = New(path)
+	t.Cleanup(storage.Shutdown)
+
+	if storage.Recovery() != (Recovery{}) {
+		t.Errorf("RecoveryNothingDropped expect empty report, got %+v", storage.Recovery())
+	}
+}
+
+func Test_eventStorage_RecoveryBrokenLength(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path, WithFormat(FormatFramed), WithChecksums())
+
+	for i := 0; i < 100; i++ {
+		_, _ = storage.Write([]byte(fmt.Sprintf("event-%02d", i)))
+	}
+
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	// Flip bit of the 10th event length, every event is 20 bytes with its header.
+	name := storage.getFilePath(storage.getFileName(1))
+	data, _ := os.ReadFile(name)
+	data[10*20] ^= 0x10
+	_ = os.WriteFile(name, data, 0644)
+
+	var corruption *CorruptionError
+
+	if _, err := New(path); !errors.As(err, &corruption) || corruption.Position != 200 {
+		t.Errorf("RecoveryBrokenLength expect CorruptionError at position 200, got %v", err)
+	}
+
+	if info, _ := os.Stat(name); info.Size() != int64(len(data)) {
+		t.Errorf("RecoveryBrokenLength expect not truncated events file, got size %v", info.Size())
+	}
+}
+
+func Test_eventStorage_RecoverySealedFile(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path)
+	storage.SetWriteFileMaxSize(100)
+
+	for i := 0; i < 40; i++ {
+		_, _ = storage.Write([]byte(fmt.Sprintf("event-%02d", i)))
+	}
+
+	_, _ = storage.Flush()
+	storage.Shutdown()
+
+	// Sealed file without its last line break.
+	name := storage.getFilePath(storage.getFileName(1))
+	info, _ := os.Stat(name)
+	_ = os.Truncate(name, info.Size()-1)
+
+	var corruption *CorruptionError
+
+	if _, err := New(path); !errors.As(err, &corruption) || corruption.File != 1 {
+		t.Errorf("RecoverySealedFile expect CorruptionError of file 1, got %v", err)
+	}
+
+	if _, err := OpenReadOnly(path); !errors.As(err, &corruption) {
+		t.Errorf("RecoverySealedFile expect CorruptionError of read-only storage, got %v", err)
+	}
+}
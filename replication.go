@@ -0,0 +1,177 @@
+package eventstorage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Names of storage files, which follower mirrors as a whole.
+const (
+	RegistryFileName  = registryFileName  // Registry of events files and storage settings.
+	ConsumersFileName = consumersFileName // Offsets committed by named consumers.
+)
+
+// ReplicaFile is kept events file, which is copied by follower as is.
+type ReplicaFile struct {
+	Name        string // File name in base path.
+	Size        int64  // Size of file data to copy, flushed data of current file or whole sealed file.
+	FirstOffset int    // Offset of the first file event.
+	Count       int    // Count of events in Size bytes.
+}
+
+// ReplicaState is consistent snapshot of registry and kept events files for replication.
+type ReplicaState struct {
+	Registry  []byte        // Registry content listing Files.
+	Files     []ReplicaFile // Kept events files in registry order.
+	Indexes   []ReplicaFile // Index and compressed blocks files of Files, they are appended like Files.
+	Consumers []byte        // Consumers file content, empty without committed consumers.
+}
+
+// ReplicaState returns snapshot of registry and kept events files for follower.
+func (s *EventStorage) ReplicaState() (ReplicaState, error) {
+	s.consumersLocker.Lock()
+	consumers := s.consumersData()
+	s.consumersLocker.Unlock()
+
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	state := ReplicaState{Registry: []byte(s.registryData()), Files: make([]ReplicaFile, 0, len(s.files))}
+
+	if consumers != "" {
+		state.Consumers = []byte(consumers)
+	}
+
+	for _, file := range s.files {
+		size := file.size
+
+		if file.compressed {
+			info, err := file.reader.Stat()
+
+			if err != nil {
+				return state, errors.New("failed to stat events file: " + err.Error())
+			}
+
+			size = info.Size()
+		}
+
+		state.Files = append(state.Files, ReplicaFile{
+			Name:        s.getEventsFileName(file),
+			Size:        size,
+			FirstOffset: file.firstOffset,
+			Count:       file.count,
+		})
+
+		for _, name := range s.indexFileNames(file) {
+			state.Indexes = append(state.Indexes, ReplicaFile{
+				Name:        name,
+				Size:        int64(len(s.indexFileData(file, name))),
+				FirstOffset: file.firstOffset,
+			})
+		}
+	}
+
+	return state, nil
+}
+
+// ReadFileAt reads bytes of kept events file or its index file starting at offset, like io.ReaderAt.
+// Reading of current file is bounded by flushed data. It returns ErrFileNotKept for file,
+// which was removed by retention or replaced by compressed one, follower should take new ReplicaState.
+func (s *EventStorage) ReadFileAt(name string, p []byte, off int64) (int, error) {
+	s.read.locker.Lock()
+	defer s.read.locker.Unlock()
+
+	s.filesLocker.RLock()
+	var file *eventsFile
+	var index []byte
+
+	for _, kept := range s.files {
+		if s.getEventsFileName(kept) == name {
+			copied := *kept
+			file = &copied
+			break
+		}
+
+		if index = s.indexFileData(kept, name); index != nil {
+			break
+		}
+	}
+
+	s.filesLocker.RUnlock()
+
+	if index != nil {
+		if off >= int64(len(index)) {
+			return 0, io.EOF
+		}
+
+		n := copy(p, index[off:])
+
+		if n < len(p) {
+			return n, io.EOF
+		}
+
+		return n, nil
+	}
+
+	if file == nil {
+		return 0, ErrFileNotKept
+	}
+
+	if file.compressed {
+		return file.reader.ReadAt(p, off)
+	}
+
+	if off >= file.size {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > file.size-off {
+		p = p[:file.size-off]
+	}
+
+	return file.reader.ReadAt(p, off)
+}
+
+// IsReplicaFileName reports whether name is exactly name of events file or its index or blocks file,
+// as listed by ReplicaState. Follower removes such files only, when leader does not keep them.
+func IsReplicaFileName(name string) bool {
+	var number int
+
+	if _, err := fmt.Sscanf(name, eventsFileNameTemplate, &number); err != nil || number < 0 {
+		return false
+	}
+
+	plain := fmt.Sprintf(eventsFileNameTemplate, number)
+
+	switch name {
+	case plain, plain + indexFileSuffix, plain + compressedFileSuffix, plain + compressedFileSuffix + blocksFileSuffix:
+		return true
+	}
+
+	return false
+}
+
+// indexFileNames returns names of index file and compressed blocks file of events file.
+func (s *EventStorage) indexFileNames(file *eventsFile) []string {
+	names := []string{s.getFileName(file.number) + indexFileSuffix}
+
+	if file.compressed && len(file.blocks) > 0 {
+		names = append(names, s.getEventsFileName(file)+blocksFileSuffix)
+	}
+
+	return names
+}
+
+// indexFileData returns content of index or blocks file of events file by its name, nil for other names.
+// Content is encoded from memory, so it matches flushed events. It must be called under files locker.
+func (s *EventStorage) indexFileData(file *eventsFile, name string) []byte {
+	switch {
+	case name == s.getFileName(file.number)+indexFileSuffix:
+		return encodeIndex([]byte{}, file.positions, file.times)
+	case file.compressed && len(file.blocks) > 0 && name == s.getEventsFileName(file)+blocksFileSuffix:
+		return encodeIndex([]byte{}, file.blocks, nil)
+	}
+
+	return nil
+}
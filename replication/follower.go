@@ -0,0 +1,368 @@
+// Package replication keeps hot standby copy of events storage directory.
+package replication
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pankif/eventstorage"
+)
+
+const (
+	tmpFileSuffix       = ".tmp" // Suffix of registry and consumers files, while follower writes them.
+	copyBufSize         = 1 << 20
+	defaultPollInterval = 100 * time.Millisecond
+	maxSyncAttempts     = 3 // Sync attempts, when leader removes or compresses file during sync.
+)
+
+// Leader is source of replication, local *eventstorage.EventStorage or remote *client.Client.
+type Leader interface {
+	ReplicaState() (eventstorage.ReplicaState, error)
+	ReadFileAt(name string, p []byte, off int64) (int, error)
+}
+
+// Lag is replication delay of follower.
+type Lag struct {
+	Events int       // Count of leader events, which are not replicated yet.
+	Bytes  int64     // Size of leader events files data, which is not replicated yet.
+	Synced time.Time // Time of the last completed sync, zero before the first one.
+}
+
+// Follower copies sealed events files and flushed tail of current file from leader into its base path
+// with their index files, and mirrors leader registry and consumers offsets, so base path may be opened
+// by eventstorage.New after failover. Position of replication is size of copied files, so follower resumes
+// after restart. Base path must not be written by anybody else, while follower runs.
+type Follower struct {
+	leader       Leader
+	basePath     string
+	fsys         eventstorage.FS
+	pollInterval time.Duration
+	buf          []byte
+	locker       sync.Mutex // Lag lock.
+	lag          Lag
+}
+
+// NewFollower returns follower of leader, which copies events files into existing base path.
+func NewFollower(leader Leader, basePath string) *Follower {
+	return &Follower{
+		leader:       leader,
+		basePath:     basePath,
+		fsys:         eventstorage.OSFS{},
+		pollInterval: defaultPollInterval,
+		buf:          make([]byte, copyBufSize),
+	}
+}
+
+// SetFS sets file system of base path, OSFS is used by default.
+func (f *Follower) SetFS(fsys eventstorage.FS) {
+	f.fsys = fsys
+}
+
+// SetPollInterval sets period of leader polling by Run, when follower caught up with leader.
+func (f *Follower) SetPollInterval(interval time.Duration) {
+	f.pollInterval = interval
+}
+
+// Lag returns replication delay as of the last sync.
+func (f *Follower) Lag() Lag {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	return f.lag
+}
+
+// Run syncs follower with leader until context is done or sync fails.
+func (f *Follower) Run(ctx context.Context) error {
+	for {
+		copied, err := f.sync()
+
+		if err != nil {
+			return err
+		}
+
+		if copied > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(f.pollInterval):
+		}
+	}
+}
+
+// Sync copies events files data flushed by leader and replaces registry by leader one.
+func (f *Follower) Sync() error {
+	_, err := f.sync()
+
+	return err
+}
+
+// sync returns count of copied bytes.
+func (f *Follower) sync() (copied int64, err error) {
+	for attempt := 1; ; attempt++ {
+		copied, err = f.syncState()
+
+		if err != eventstorage.ErrFileNotKept || attempt == maxSyncAttempts {
+			return copied, err
+		}
+	}
+}
+
+func (f *Follower) syncState() (copied int64, err error) {
+	state, err := f.leader.ReplicaState()
+
+	if err != nil {
+		return 0, err
+	}
+
+	sizes := make([]int64, len(state.Files))
+	lag := Lag{Synced: f.Lag().Synced}
+
+	for i, file := range state.Files {
+		if sizes[i], err = f.localSize(file); err != nil {
+			return 0, err
+		}
+
+		if sizes[i] < file.Size {
+			// Events of partially copied file are not counted by bytes, so whole file is counted.
+			lag.Events += file.Count
+			lag.Bytes += file.Size - sizes[i]
+		}
+	}
+
+	for i, file := range state.Files {
+		n, err := f.copyFile(file, sizes[i])
+		copied += n
+		lag.Bytes -= n
+
+		if err != nil {
+			f.setLag(lag)
+			return copied, err
+		}
+
+		if n > 0 {
+			lag.Events -= file.Count
+			f.setLag(lag)
+		}
+	}
+
+	// Index files are small, so they are not counted by lag.
+	for _, file := range state.Indexes {
+		size, err := f.localSize(file)
+
+		if err != nil {
+			return copied, err
+		}
+
+		n, err := f.copyFile(file, size)
+		copied += n
+
+		if err != nil {
+			return copied, err
+		}
+	}
+
+	if err = f.replaceFile(eventstorage.ConsumersFileName, state.Consumers); err != nil {
+		return copied, err
+	}
+
+	if err = f.replaceFile(eventstorage.RegistryFileName, state.Registry); err != nil {
+		return copied, err
+	}
+
+	if err = f.removeNotKept(state); err != nil {
+		return copied, err
+	}
+
+	lag.Synced = time.Now()
+	f.setLag(lag)
+
+	return copied, nil
+}
+
+// localSize returns size of copied file data, data over leader file size is dropped.
+func (f *Follower) localSize(file eventstorage.ReplicaFile) (int64, error) {
+	info, err := f.fsys.Stat(f.getFilePath(file.Name))
+
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.New("failed to stat events file: " + err.Error())
+	}
+
+	if info.Size() > file.Size {
+		// Leader dropped events, which were not synced to its disk before crash.
+		if err = f.truncate(file.Name, file.Size); err != nil {
+			return 0, errors.New("failed to truncate events file: " + err.Error())
+		}
+
+		return file.Size, nil
+	}
+
+	return info.Size(), nil
+}
+
+// copyFile appends leader file data after size and syncs it.
+func (f *Follower) copyFile(file eventstorage.ReplicaFile, size int64) (copied int64, err error) {
+	if size >= file.Size && file.Size > 0 {
+		return 0, nil
+	}
+
+	local, err := f.fsys.OpenFile(f.getFilePath(file.Name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return 0, errors.New("failed to open events file: " + err.Error())
+	}
+
+	defer local.Close()
+
+	for size+copied < file.Size {
+		chunk := f.buf
+
+		if remain := file.Size - size - copied; remain < int64(len(chunk)) {
+			chunk = chunk[:remain]
+		}
+
+		n, err := f.leader.ReadFileAt(file.Name, chunk, size+copied)
+
+		if n > 0 {
+			if _, writeErr := local.Write(chunk[:n]); writeErr != nil {
+				return copied, errors.New("failed to write events file: " + writeErr.Error())
+			}
+
+			copied += int64(n)
+		}
+
+		if err == io.EOF && n == 0 {
+			return copied, errors.New("leader events file " + file.Name + " is shorter than its state")
+		} else if err != nil && err != io.EOF {
+			return copied, err
+		}
+	}
+
+	if err = local.Sync(); err != nil {
+		return copied, errors.New("failed to sync events file: " + err.Error())
+	}
+
+	return copied, nil
+}
+
+// replaceFile replaces registry or consumers file by leader one, when it changed.
+// Empty data means that leader has no such file.
+func (f *Follower) replaceFile(name string, data []byte) error {
+	path := f.getFilePath(name)
+	current, err := f.readFile(path)
+
+	if len(data) == 0 {
+		if err = f.fsys.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.New("failed to remove " + name + ": " + err.Error())
+		}
+
+		return nil
+	} else if err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	tmpPath := path + tmpFileSuffix
+	file, err := f.fsys.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return errors.New("failed to create " + name + ": " + err.Error())
+	}
+
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.New("failed to write " + name + ": " + err.Error())
+	}
+
+	if err = f.fsys.Rename(tmpPath, path); err != nil {
+		return errors.New("failed to replace " + name + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// removeNotKept removes events files and their index files, which leader removed by retention
+// or replaced by compressed ones. Other files of base path are never removed.
+func (f *Follower) removeNotKept(state eventstorage.ReplicaState) error {
+	kept := make(map[string]bool, len(state.Files)+len(state.Indexes))
+
+	for _, file := range state.Files {
+		kept[file.Name] = true
+	}
+
+	for _, file := range state.Indexes {
+		kept[file.Name] = true
+	}
+
+	entries, err := f.fsys.ReadDir(f.basePath)
+
+	if err != nil {
+		return errors.New("failed to list events files: " + err.Error())
+	}
+
+	for _, entry := range entries {
+		if kept[entry.Name()] || !eventstorage.IsReplicaFileName(entry.Name()) {
+			continue
+		}
+
+		if err = f.fsys.Remove(f.getFilePath(entry.Name())); err != nil && !os.IsNotExist(err) {
+			return errors.New("failed to remove events file: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (f *Follower) setLag(lag Lag) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	f.lag = lag
+}
+
+func (f *Follower) getFilePath(fileName string) string {
+	return f.basePath + string(os.PathSeparator) + fileName
+}
+
+func (f *Follower) readFile(path string) ([]byte, error) {
+	file, err := f.fsys.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+func (f *Follower) truncate(name string, size int64) error {
+	file, err := f.fsys.OpenFile(f.getFilePath(name), os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	err = file.Truncate(size)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
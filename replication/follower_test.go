@@ -0,0 +1,242 @@
+package replication
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pankif/eventstorage"
+	"github.com/pankif/eventstorage/client"
+	"github.com/pankif/eventstorage/server"
+)
+
+func writeEvents(leader *eventstorage.EventStorage, from int, to int) {
+	for i := from; i < to; i++ {
+		_, _ = leader.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = leader.Flush()
+}
+
+func checkReplica(t *testing.T, path string, leader *eventstorage.EventStorage) {
+	state, _ := leader.ReplicaState()
+	registry, _ := os.ReadFile(path + string(os.PathSeparator) + eventstorage.RegistryFileName)
+
+	if string(registry) != string(state.Registry) {
+		t.Errorf("Follower expect registry %q, got %q", state.Registry, registry)
+	}
+
+	replica, err := eventstorage.OpenReadOnly(path)
+
+	if err != nil {
+		t.Fatalf("Follower replica failed to open, err: %v", err)
+	}
+
+	defer replica.Shutdown()
+
+	files := leader.Files()
+	first := files[0].FirstOffset
+	count := files[len(files)-1].FirstOffset + files[len(files)-1].Count - first
+	expected, _ := leader.Read(count, first)
+	events, err := replica.Read(count+1, first)
+
+	if err != nil || len(events) != len(expected) || events[len(events)-1] != expected[len(expected)-1] {
+		t.Errorf("Follower replica expect %v events, got %v, err: %v", len(expected), len(events), err)
+	}
+}
+
+func waitCompressed(t *testing.T, leader *eventstorage.EventStorage) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		files := leader.Files()
+		compressed := 0
+
+		for _, file := range files {
+			if file.Compressed {
+				compressed++
+			}
+		}
+
+		if compressed == len(files)-1 {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Follower expect leader files compressed, got %+v", files)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_Follower_Sync(t *testing.T) {
+	dir := t.TempDir()
+	leaderPath, followerPath := dir+"/leader", dir+"/follower"
+	_ = os.Mkdir(leaderPath, 0755)
+	_ = os.Mkdir(followerPath, 0755)
+
+	leader, _ := eventstorage.New(leaderPath, eventstorage.WithFormat(eventstorage.FormatFramed))
+	t.Cleanup(leader.Shutdown)
+	leader.SetWriteFileMaxSize(200)
+	writeEvents(leader, 0, 30)
+
+	follower := NewFollower(leader, followerPath)
+
+	if err := follower.Sync(); err != nil {
+		t.Fatalf("Follower Sync failed, err: %v", err)
+	}
+
+	checkReplica(t, followerPath, leader)
+
+	if lag := follower.Lag(); lag.Events != 0 || lag.Bytes != 0 || lag.Synced.IsZero() {
+		t.Errorf("Follower expect no lag after sync, got %+v", lag)
+	}
+
+	// Follower restarts, while leader compresses and removes files.
+	writeEvents(leader, 30, 60)
+	leader.SetCompression(eventstorage.CompressionGzip)
+	_ = leader.SetRetention(eventstorage.Retention{MaxFiles: 4})
+	waitCompressed(t, leader)
+	follower = NewFollower(leader, followerPath)
+
+	if err := follower.Sync(); err != nil {
+		t.Fatalf("Follower Sync after restart failed, err: %v", err)
+	}
+
+	checkReplica(t, followerPath, leader)
+	entries, _ := os.ReadDir(followerPath)
+	state, _ := leader.ReplicaState()
+
+	if len(entries) != len(state.Files)+len(state.Indexes)+1 {
+		t.Errorf("Follower expect %v events files, %v index files and registry, got %v", len(state.Files), len(state.Indexes), len(entries))
+	}
+
+	for _, file := range state.Indexes {
+		local, _ := os.ReadFile(followerPath + string(os.PathSeparator) + file.Name)
+		expected := make([]byte, file.Size+1)
+		n, _ := leader.ReadFileAt(file.Name, expected, 0)
+
+		if string(local) != string(expected[:n]) {
+			t.Errorf("Follower expect index file %v copied, got %v", file.Name, local)
+		}
+	}
+}
+
+func Test_Follower_SideFiles(t *testing.T) {
+	dir := t.TempDir()
+	leaderPath, followerPath := dir+"/leader", dir+"/follower"
+	_ = os.Mkdir(leaderPath, 0755)
+	_ = os.Mkdir(followerPath, 0755)
+
+	leader, _ := eventstorage.New(leaderPath)
+	t.Cleanup(leader.Shutdown)
+	writeEvents(leader, 0, 10)
+	_ = leader.Commit("consumer", 5)
+
+	// Files of base path, which follower never wrote, are kept.
+	stray := followerPath + string(os.PathSeparator) + "events.1.bak"
+	_ = os.WriteFile(stray, []byte("backup"), 0644)
+	follower := NewFollower(leader, followerPath)
+
+	if err := follower.Sync(); err != nil {
+		t.Fatalf("Follower Sync failed, err: %v", err)
+	}
+
+	if _, err := os.Stat(stray); err != nil {
+		t.Errorf("Follower expect not own file kept, err: %v", err)
+	}
+
+	replica, err := eventstorage.OpenReadOnly(followerPath)
+
+	if err != nil {
+		t.Fatalf("Follower replica failed to open, err: %v", err)
+	}
+
+	defer replica.Shutdown()
+
+	if replica.Committed("consumer") != 5 {
+		t.Errorf("Follower expect consumer offset 5, got %v", replica.Committed("consumer"))
+	}
+}
+
+func Test_Follower_FS(t *testing.T) {
+	leader, _ := eventstorage.New(t.TempDir())
+	t.Cleanup(leader.Shutdown)
+	writeEvents(leader, 0, 10)
+
+	fsys := eventstorage.NewMemFS()
+	_ = fsys.MkdirAll("events", 0755)
+	follower := NewFollower(leader, "events")
+	follower.SetFS(fsys)
+
+	if err := follower.Sync(); err != nil {
+		t.Fatalf("Follower Sync failed, err: %v", err)
+	}
+
+	replica, err := eventstorage.New("events", eventstorage.WithFS(fsys))
+
+	if err != nil {
+		t.Fatalf("Follower replica failed to open, err: %v", err)
+	}
+
+	defer replica.Shutdown()
+
+	if events, err := replica.Read(20, 0); err != nil || len(events) != 10 || events[9] != "event 9" {
+		t.Errorf("Follower expect 10 events in memory FS, got %q, err: %v", events, err)
+	}
+}
+
+func Test_Follower_RunOverTCP(t *testing.T) {
+	dir := t.TempDir()
+	leaderPath, followerPath := dir+"/leader", dir+"/follower"
+	_ = os.Mkdir(leaderPath, 0755)
+	_ = os.Mkdir(followerPath, 0755)
+
+	leader, _ := eventstorage.New(leaderPath)
+	srv := server.New(leader)
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	go func() { _ = srv.Serve(listener) }()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+		leader.Shutdown()
+	})
+
+	c, err := client.Dial(listener.Addr().String())
+
+	if err != nil {
+		t.Fatalf("Follower failed to connect, err: %v", err)
+	}
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	follower := NewFollower(c, followerPath)
+	follower.SetPollInterval(5 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+
+	go func() { stopped <- follower.Run(ctx) }()
+
+	writeEvents(leader, 0, 10)
+	deadline := time.Now().Add(5 * time.Second)
+
+	for follower.Lag().Synced.IsZero() || follower.Lag().Events != 0 || follower.Lag().Bytes != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Follower expect catching up, got lag %+v", follower.Lag())
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+
+	if err = <-stopped; err != context.Canceled {
+		t.Errorf("Follower Run expect %v, got %v", context.Canceled, err)
+	}
+
+	checkReplica(t, followerPath, leader)
+}
@@ -0,0 +1,86 @@
+package eventstorage
+
+import (
+	"io"
+	"testing"
+)
+
+func Test_eventStorage_ReadFileAt(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	_, _ = storage.Write([]byte("flushed"))
+	_, _ = storage.Flush()
+	_, _ = storage.Write([]byte("not flushed"))
+
+	state, err := storage.ReplicaState()
+
+	if err != nil || len(state.Files) != 1 || state.Files[0].Size != 8 || state.Files[0].Count != 1 {
+		t.Fatalf("ReplicaState expect one file with flushed event, got %+v, err: %v", state, err)
+	}
+
+	if string(state.Registry) != "#format=lines\n#offset=0\nevents.1\n" {
+		t.Errorf("ReplicaState expect registry of events.1, got %q", state.Registry)
+	}
+
+	buf := make([]byte, 100)
+	n, err := storage.ReadFileAt("events.1", buf, 0)
+
+	if err != nil || string(buf[:n]) != "flushed\n" {
+		t.Errorf("ReadFileAt expect flushed data only, got %q, err: %v", buf[:n], err)
+	}
+
+	if _, err = storage.ReadFileAt("events.1", buf, 8); err != io.EOF {
+		t.Errorf("ReadFileAt expect %v after flushed data, got %v", io.EOF, err)
+	}
+
+	if _, err = storage.ReadFileAt("events.2", buf, 0); err != ErrFileNotKept {
+		t.Errorf("ReadFileAt expect %v, got %v", ErrFileNotKept, err)
+	}
+}
+
+func Test_eventStorage_ReplicaStateIndexes(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	_, _ = storage.Write([]byte("flushed"))
+	_, _ = storage.Flush()
+	_ = storage.Commit("consumer", 1)
+
+	state, err := storage.ReplicaState()
+
+	if err != nil || len(state.Indexes) != 1 || state.Indexes[0].Name != "events.1.idx" || state.Indexes[0].Size != indexEntrySize {
+		t.Fatalf("ReplicaState expect index of events.1, got %+v, err: %v", state.Indexes, err)
+	}
+
+	if string(state.Consumers) != "consumer 1\n" {
+		t.Errorf("ReplicaState expect committed consumer, got %q", state.Consumers)
+	}
+
+	buf := make([]byte, 100)
+	n, err := storage.ReadFileAt("events.1.idx", buf, 0)
+	index, _ := storage.readFile(storage.getIndexPath(1))
+
+	if err != io.EOF || string(buf[:n]) != string(index) {
+		t.Errorf("ReadFileAt expect index file %v, got %v, err: %v", index, buf[:n], err)
+	}
+}
+
+func Test_IsReplicaFileName(t *testing.T) {
+	names := map[string]bool{
+		"events.1":           true,
+		"events.1.idx":       true,
+		"events.1.gz":        true,
+		"events.1.gz.blocks": true,
+		"events.01":          false,
+		"events.1.bak":       false,
+		"events.1.gz.tmp":    false,
+		"events.lock":        false,
+		RegistryFileName:     false,
+		ConsumersFileName:    false,
+	}
+
+	for name, expected := range names {
+		if IsReplicaFileName(name) != expected {
+			t.Errorf("IsReplicaFileName expect %v for %q", expected, name)
+		}
+	}
+}
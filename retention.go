@@ -0,0 +1,180 @@
+package eventstorage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Retention limits events files kept by storage, the oldest sealed files are removed first.
+// The current events file is never removed, offsets of kept events stay the same.
+type Retention struct {
+	MaxFiles int           // Max count of events files, 0 - unlimited.
+	MaxBytes int64         // Max total size of events files, 0 - unlimited.
+	MaxAge   time.Duration // Max age of sealed events file since its last write, 0 - unlimited.
+}
+
+// SetRetention sets retention policy and applies it, policy is applied on every events file rotation as well.
+func (s *EventStorage) SetRetention(retention Retention) error {
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	s.write.retention = retention
+
+	return s.applyRetention()
+}
+
+// ApplyRetention removes events files out of retention policy, for example by MaxAge without writes.
+func (s *EventStorage) ApplyRetention() error {
+	s.write.locker.Lock()
+	defer s.write.locker.Unlock()
+
+	return s.applyRetention()
+}
+
+func (s *EventStorage) applyRetention() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	expired, err := s.expiredFilesCount()
+
+	if err != nil || expired == 0 {
+		return err
+	}
+
+	s.read.locker.Lock()
+	defer s.read.locker.Unlock()
+
+	s.filesLocker.Lock()
+	removed := s.files[:expired]
+	s.files = s.files[expired:]
+	s.baseOffset = s.files[0].firstOffset
+	s.filesLocker.Unlock()
+
+	if err = s.rewriteRegistry(); err != nil {
+		return err
+	}
+
+	for _, file := range removed {
+		_ = file.reader.Close()
+
+		if err = s.fsys.Remove(s.getFilePath(s.getEventsFileName(file))); err != nil {
+			return errors.New("failed to remove events file: " + err.Error())
+		}
+
+		if err = s.fsys.Remove(s.getIndexPath(file.number)); err != nil && !os.IsNotExist(err) {
+			return errors.New("failed to remove index file: " + err.Error())
+		}
+
+		if err = s.fsys.Remove(s.getBlocksPath(file.number)); err != nil && !os.IsNotExist(err) {
+			return errors.New("failed to remove compressed blocks file: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// expiredFilesCount returns count of the oldest sealed files out of retention policy.
+func (s *EventStorage) expiredFilesCount() (int, error) {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	retention := s.write.retention
+	sealed := len(s.files) - 1
+	expired := 0
+
+	if retention.MaxFiles > 0 && len(s.files) > retention.MaxFiles {
+		expired = len(s.files) - retention.MaxFiles
+	}
+
+	if retention.MaxBytes > 0 {
+		var totalSize int64
+
+		for _, file := range s.files {
+			totalSize += file.size
+		}
+
+		for i := 0; i < sealed && totalSize > retention.MaxBytes; i++ {
+			totalSize -= s.files[i].size
+
+			if i+1 > expired {
+				expired = i + 1
+			}
+		}
+	}
+
+	if retention.MaxAge > 0 {
+		for i := expired; i < sealed; i++ {
+			info, err := s.files[i].reader.Stat()
+
+			if err != nil {
+				return 0, errors.New("failed to stat events file: " + err.Error())
+			}
+
+			if time.Since(info.ModTime()) <= retention.MaxAge {
+				break
+			}
+
+			expired = i + 1
+		}
+	}
+
+	if expired > sealed {
+		expired = sealed
+	}
+
+	return expired, nil
+}
+
+// registryData returns registry content of current settings and files list, it must be called under filesLocker.
+func (s *EventStorage) registryData() string {
+	data := s.codec.settings() + string(registryHeader) + "offset=" + strconv.Itoa(s.baseOffset) + "\n"
+
+	for _, file := range s.files {
+		data += s.registryFileLine(file) + "\n"
+	}
+
+	return data
+}
+
+// rewriteRegistry replaces registry by a new one with current settings and files list.
+func (s *EventStorage) rewriteRegistry() error {
+	s.filesLocker.RLock()
+	data := s.registryData()
+	s.filesLocker.RUnlock()
+
+	tmpPath := s.getFilePath(registryFileName + tmpFileSuffix)
+	registry, err := s.fsys.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_APPEND|os.O_RDWR, 0644)
+
+	if err != nil {
+		return errors.New("failed to create registry: " + err.Error())
+	}
+
+	if _, err = io.WriteString(registry, data); err == nil && s.write.durability != DurabilityNone {
+		err = registry.Sync()
+	}
+
+	if err != nil {
+		_ = registry.Close()
+		return errors.New("failed to write registry: " + err.Error())
+	}
+
+	if err = s.fsys.Rename(tmpPath, s.getFilePath(registryFileName)); err != nil {
+		_ = registry.Close()
+		return errors.New("failed to replace registry: " + err.Error())
+	}
+
+	_ = s.filesRegistry.Close()
+	s.filesRegistry = registry
+
+	if s.write.durability != DurabilityNone {
+		if err = s.fsys.SyncDir(s.basePath); err != nil {
+			return errors.New("failed sync storage directory: " + err.Error())
+		}
+	}
+
+	return nil
+}
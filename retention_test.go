@@ -0,0 +1,116 @@
+package eventstorage
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func retentionFillStorage(path string) *EventStorage {
+	storage, _ := New(path)
+	storage.SetWriteFileMaxSize(30)
+
+	for i := 0; i < 20; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+
+	return storage
+}
+
+func Test_eventStorage_RetentionMaxFiles(t *testing.T) {
+	path := t.TempDir()
+	storage := retentionFillStorage(path)
+
+	if err := storage.SetRetention(Retention{MaxFiles: 2}); err != nil {
+		t.Errorf("RetentionMaxFiles failed, err: %v", err)
+		return
+	}
+
+	if len(storage.files) != 2 {
+		t.Errorf("RetentionMaxFiles expect 2 files, got %v", len(storage.files))
+	}
+
+	if _, err := os.Stat(storage.getFilePath(storage.getFileName(1))); !os.IsNotExist(err) {
+		t.Errorf("RetentionMaxFiles expect the oldest file removed")
+	}
+
+	firstKept := storage.files[0].firstOffset
+	storage.Shutdown()
+
+	storage, err := New(path)
+
+	if err != nil {
+		t.Errorf("RetentionMaxFiles failed to open, err: %v", err)
+		return
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if _, err = storage.Read(1, firstKept-1); err != ErrOffsetRemoved {
+		t.Errorf("RetentionMaxFiles expect removed offset error, got %v", err)
+	}
+
+	if events, err := storage.Read(1, firstKept); err != nil || len(events) != 1 || events[0] != "event "+strconv.Itoa(firstKept) {
+		t.Errorf("RetentionMaxFiles expect stable offsets, got %q, err: %v", events, err)
+	}
+}
+
+func Test_eventStorage_RetentionMaxBytes(t *testing.T) {
+	storage := retentionFillStorage(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	_ = storage.SetRetention(Retention{MaxBytes: 40})
+
+	var totalSize int64
+
+	for _, file := range storage.files {
+		totalSize += file.size
+	}
+
+	if totalSize > 40 {
+		t.Errorf("RetentionMaxBytes expect total size up to 40, got %v", totalSize)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, _ = storage.Write([]byte("event"))
+	}
+
+	if len(storage.files) > 2 {
+		t.Errorf("RetentionMaxBytes expect policy applied on rotation, got %v files", len(storage.files))
+	}
+}
+
+func Test_eventStorage_RetentionMaxAge(t *testing.T) {
+	storage := retentionFillStorage(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	_ = storage.SetRetention(Retention{MaxAge: time.Hour})
+	filesCount := len(storage.files)
+	old := time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(storage.getFilePath(storage.getFileName(1)), old, old)
+	_ = os.Chtimes(storage.getFilePath(storage.getFileName(2)), old, old)
+
+	if err := storage.ApplyRetention(); err != nil {
+		t.Errorf("RetentionMaxAge failed, err: %v", err)
+		return
+	}
+
+	if len(storage.files) != filesCount-2 || storage.files[0].number != 3 {
+		t.Errorf("RetentionMaxAge expect 2 old files removed, got %v files", len(storage.files))
+	}
+}
+
+func Test_eventStorage_RetentionKeepsCurrentFile(t *testing.T) {
+	storage := retentionFillStorage(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	current := storage.files[len(storage.files)-1].number
+	_ = storage.SetRetention(Retention{MaxFiles: 1, MaxBytes: 1})
+
+	if len(storage.files) != 1 || storage.files[0].number != current {
+		t.Errorf("RetentionKeepsCurrentFile expect only current file kept")
+	}
+}
@@ -0,0 +1,169 @@
+package eventstorage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ReadLast reads up to n the newest flushed events, in write order. It returns no events for n <= 0.
+func (s *EventStorage) ReadLast(n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	s.read.locker.Lock()
+	defer s.read.locker.Unlock()
+
+	end := s.flushedOffset()
+	start := end - n
+
+	if start < s.baseOffset {
+		start = s.baseOffset
+	}
+
+	events := make([]string, end-start)
+	saved, err := s.readTo(context.Background(), end-start, start, events)
+
+	return events[:saved], err
+}
+
+// ReverseCursor reads events one by one backwards, from the newest event flushed before cursor creation
+// to the first kept event. Events are read by index blocks, so it never scans events files from the beginning.
+// Exception is file compressed as one gzip member by older versions, it is decompressed from the beginning
+// for every block, so reading of such file is quadratic.
+// ReverseCursor is not safe for concurrent use.
+type ReverseCursor struct {
+	storage    *EventStorage
+	offset     int           // Offset after the next event.
+	file       eventsFile    // Current events file, its reader is cursor own file handle.
+	reader     *recordReader // For read events of current file.
+	block      [][]byte      // Events of current index block, in write order.
+	times      []int64       // Write times of current index block events, with timestamps enabled.
+	blockStart int           // Offset of the first event of current index block.
+	time       int64         // Write time of the last returned event.
+	closed     bool
+}
+
+// ReverseCursor returns cursor, which starts from the newest flushed event.
+func (s *EventStorage) ReverseCursor() *ReverseCursor {
+	offset := s.flushedOffset()
+
+	return &ReverseCursor{
+		storage:    s,
+		offset:     offset,
+		reader:     newRecordReader(make([]byte, readBufLimit), s.codec),
+		blockStart: offset,
+	}
+}
+
+// Next returns the previous event, io.EOF means the first kept event was already returned.
+// Returned data is owned by caller.
+func (c *ReverseCursor) Next() ([]byte, error) {
+	if c.closed {
+		return nil, ErrCursorClosed
+	}
+
+	if c.offset <= c.blockStart {
+		if err := c.readBlock(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.offset--
+	i := c.offset - c.blockStart
+
+	if c.times != nil {
+		c.time = c.times[i]
+	}
+
+	return c.block[i], nil
+}
+
+// Offset returns offset of the event, which will be returned by the next call of Next.
+func (c *ReverseCursor) Offset() int {
+	return c.offset - 1
+}
+
+// Time returns write time of the event returned by the last call of Next, with timestamps enabled.
+func (c *ReverseCursor) Time() time.Time {
+	if c.time == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, c.time)
+}
+
+// Close releases cursor file handle.
+func (c *ReverseCursor) Close() error {
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+
+	if c.file.reader != nil {
+		return c.file.reader.Close()
+	}
+
+	return nil
+}
+
+// readBlock reads index block with the event before cursor offset.
+func (c *ReverseCursor) readBlock() error {
+	if c.offset <= 0 {
+		return io.EOF
+	}
+
+	if c.file.reader == nil || c.offset-1 < c.file.firstOffset {
+		if err := c.prevFile(); err != nil {
+			return err
+		}
+	}
+
+	fileOffset := (c.offset - 1 - c.file.firstOffset) / indexInterval * indexInterval
+
+	if err := c.reader.seek(c.file, fileOffset); err != nil {
+		return c.reader.committedErr(err)
+	}
+
+	c.blockStart = c.file.firstOffset + fileOffset
+	c.block = c.block[:0]
+	c.times = nil
+
+	for offset := c.blockStart; offset < c.offset; offset++ {
+		record, err := c.reader.next()
+
+		if err != nil {
+			return c.reader.committedErr(err)
+		}
+
+		c.block = append(c.block, append([]byte(nil), record...))
+
+		if c.reader.timestamps {
+			c.times = append(c.times, c.reader.time)
+		}
+	}
+
+	return nil
+}
+
+// prevFile opens events file with the event before cursor offset.
+func (c *ReverseCursor) prevFile() error {
+	if c.file.reader != nil {
+		_ = c.file.reader.Close()
+		c.file.reader = nil
+	}
+
+	file, err := c.storage.openFileByOffset(c.offset - 1)
+
+	if err == ErrOffsetRemoved {
+		return io.EOF
+	} else if err != nil {
+		return err
+	}
+
+	c.file = file
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package eventstorage
+
+import (
+	"io"
+	"strconv"
+	"testing"
+)
+
+func Test_ReverseCursor_Next(t *testing.T) {
+	for _, compression := range []Compression{CompressionNone, CompressionGzip} {
+		storage, _ := New(t.TempDir())
+		storage.SetWriteFileMaxSize(5 * KB)
+		storage.SetCompression(compression)
+
+		const iterCount = indexInterval*2 + 10
+
+		for i := 0; i < iterCount; i++ {
+			_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+		}
+
+		_, _ = storage.Flush()
+		_, _ = storage.Write([]byte("not flushed"))
+
+		cursor := storage.ReverseCursor()
+
+		for i := iterCount - 1; i >= 0; i-- {
+			if cursor.Offset() != i {
+				t.Errorf("ReverseCursor Offset expect %v with %v compression, got %v", i, compression, cursor.Offset())
+				break
+			}
+
+			event, err := cursor.Next()
+
+			if err != nil || string(event) != "event "+strconv.Itoa(i) {
+				t.Errorf("ReverseCursor Next expect event %v with %v compression, got %q, err: %v", i, compression, event, err)
+				break
+			}
+		}
+
+		if _, err := cursor.Next(); err != io.EOF {
+			t.Errorf("ReverseCursor Next expect EOF with %v compression, got %v", compression, err)
+		}
+
+		_ = cursor.Close()
+
+		if _, err := cursor.Next(); err != ErrCursorClosed {
+			t.Errorf("ReverseCursor Next expect %v after close, got %v", ErrCursorClosed, err)
+		}
+
+		storage.Shutdown()
+	}
+}
+
+func Test_ReverseCursor_StopsAtRetention(t *testing.T) {
+	storage := retentionFillStorage(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	_ = storage.SetRetention(Retention{MaxFiles: 2})
+	_ = storage.ApplyRetention()
+
+	cursor := storage.ReverseCursor()
+	t.Cleanup(func() { _ = cursor.Close() })
+	count := 0
+
+	for ; ; count++ {
+		if _, err := cursor.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("ReverseCursor StopsAtRetention failed to read, err: %v", err)
+		}
+	}
+
+	if expected := storage.flushedOffset() - storage.baseOffset; count != expected {
+		t.Errorf("ReverseCursor StopsAtRetention expect %v events, got %v", expected, count)
+	}
+}
+
+func Test_eventStorage_ReadLast(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	storage.SetWriteFileMaxSize(KB)
+	t.Cleanup(storage.Shutdown)
+
+	for i := 0; i < 200; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+
+	events, err := storage.ReadLast(3)
+
+	if err != nil || len(events) != 3 || events[0] != "event 197" || events[2] != "event 199" {
+		t.Errorf("ReadLast expect the last 3 events, got %q, err: %v", events, err)
+	}
+
+	if events, err = storage.ReadLast(300); err != nil || len(events) != 200 || events[0] != "event 0" {
+		t.Errorf("ReadLast expect all 200 events, got %v, err: %v", len(events), err)
+	}
+
+	for _, n := range []int{0, -1} {
+		if events, err = storage.ReadLast(n); err != nil || len(events) != 0 {
+			t.Errorf("ReadLast expect no events for %v, got %q, err: %v", n, events, err)
+		}
+	}
+}
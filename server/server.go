@@ -0,0 +1,336 @@
+// Package server serves events storage over binary TCP protocol, see package protocol.
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pankif/eventstorage"
+	"github.com/pankif/eventstorage/protocol"
+)
+
+const (
+	maxReadCount    = 10000   // Max count of events of one Read command.
+	maxSubscribeBuf = 10000   // Max count of events buffered by one subscription.
+	maxReadFileSize = 1 << 20 // Max size of data of one ReadFile command.
+	connBufSize     = 64 << 10
+)
+
+var ErrServerClosed = errors.New("server closed")
+
+// Server serves events storage over TCP, every connection is served by its own goroutine.
+type Server struct {
+	storage   *eventstorage.EventStorage
+	locker    sync.Mutex
+	listeners map[net.Listener]bool
+	conns     map[net.Conn]bool
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+func New(storage *eventstorage.EventStorage) *Server {
+	return &Server{
+		storage:   storage,
+		listeners: make(map[net.Listener]bool),
+		conns:     make(map[net.Conn]bool),
+	}
+}
+
+// ListenAndServe listens on TCP address and serves connections until Close.
+func (srv *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	return srv.Serve(listener)
+}
+
+// Serve accepts connections of listener until Close, listener is closed by Close.
+func (srv *Server) Serve(listener net.Listener) error {
+	if !srv.track(listener, nil) {
+		_ = listener.Close()
+		return ErrServerClosed
+	}
+
+	for {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			if srv.isClosed() {
+				return ErrServerClosed
+			}
+
+			var netErr net.Error
+
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+
+			return err
+		}
+
+		if !srv.track(nil, conn) {
+			_ = conn.Close()
+			return ErrServerClosed
+		}
+
+		srv.wg.Add(1)
+
+		go func() {
+			defer srv.wg.Done()
+			srv.serveConn(conn)
+		}()
+	}
+}
+
+// Close closes listeners and connections and waits for connections goroutines.
+func (srv *Server) Close() error {
+	srv.locker.Lock()
+	srv.closed = true
+
+	for listener := range srv.listeners {
+		_ = listener.Close()
+	}
+
+	for conn := range srv.conns {
+		_ = conn.Close()
+	}
+
+	srv.locker.Unlock()
+	srv.wg.Wait()
+
+	return nil
+}
+
+func (srv *Server) track(listener net.Listener, conn net.Conn) bool {
+	srv.locker.Lock()
+	defer srv.locker.Unlock()
+
+	if srv.closed {
+		return false
+	}
+
+	if listener != nil {
+		srv.listeners[listener] = true
+	}
+
+	if conn != nil {
+		srv.conns[conn] = true
+	}
+
+	return true
+}
+
+func (srv *Server) isClosed() bool {
+	srv.locker.Lock()
+	defer srv.locker.Unlock()
+
+	return srv.closed
+}
+
+// serveConn handles pipelined requests, responses are sent when there are no more buffered requests.
+func (srv *Server) serveConn(conn net.Conn) {
+	defer func() {
+		srv.locker.Lock()
+		delete(srv.conns, conn)
+		srv.locker.Unlock()
+		_ = conn.Close()
+	}()
+
+	reader := bufio.NewReaderSize(conn, connBufSize)
+	writer := bufio.NewWriterSize(conn, connBufSize)
+	var request, response []byte
+	var err error
+
+	for {
+		if request, err = protocol.ReadFrame(reader, request); err != nil {
+			return
+		}
+
+		if len(request) > 0 && request[0] == protocol.CommandSubscribe {
+			srv.subscribe(reader, writer, request[1:])
+			return
+		}
+
+		response = srv.handle(request, response[:0])
+
+		if err = protocol.WriteFrame(writer, response); err != nil {
+			return
+		}
+
+		if reader.Buffered() == 0 {
+			if err = writer.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle appends response of request to response buffer.
+func (srv *Server) handle(request []byte, response []byte) []byte {
+	if len(request) == 0 {
+		return protocol.ErrorResponse(protocol.ErrMalformedFrame)
+	}
+
+	args := request[1:]
+
+	switch request[0] {
+	case protocol.CommandWrite:
+		offset, err := srv.storage.Write(args)
+
+		if err != nil {
+			return protocol.ErrorResponse(err)
+		}
+
+		return protocol.AppendInt64(append(response, protocol.StatusOK), int64(offset))
+	case protocol.CommandRead:
+		if len(args) != protocol.ReadArgsSize {
+			return protocol.ErrorResponse(protocol.ErrMalformedFrame)
+		}
+
+		offset := int(binary.LittleEndian.Uint64(args))
+		count := int(binary.LittleEndian.Uint32(args[8:]))
+
+		if count > maxReadCount {
+			count = maxReadCount
+		}
+
+		events, err := srv.storage.Read(count, offset)
+
+		if err != nil {
+			return protocol.ErrorResponse(err)
+		}
+
+		response = protocol.AppendUint32(append(response, protocol.StatusOK), uint32(len(events)))
+
+		for _, event := range events {
+			response = append(protocol.AppendUint32(response, uint32(len(event))), event...)
+		}
+
+		if len(response) > protocol.MaxFrameSize {
+			return protocol.ErrorResponse(protocol.ErrFrameTooLarge)
+		}
+
+		return response
+	case protocol.CommandFlush:
+		count, err := srv.storage.Flush()
+
+		if err != nil {
+			return protocol.ErrorResponse(err)
+		}
+
+		return protocol.AppendInt64(append(response, protocol.StatusOK), int64(count))
+	case protocol.CommandState:
+		state, err := srv.storage.ReplicaState()
+
+		if err != nil {
+			return protocol.ErrorResponse(err)
+		}
+
+		return protocol.AppendReplicaState(append(response, protocol.StatusOK), state)
+	case protocol.CommandReadFile:
+		if len(args) < protocol.ReadFileArgsMinSize {
+			return protocol.ErrorResponse(protocol.ErrMalformedFrame)
+		}
+
+		offset := int64(binary.LittleEndian.Uint64(args))
+		size := int(binary.LittleEndian.Uint32(args[8:]))
+
+		if size > maxReadFileSize {
+			size = maxReadFileSize
+		}
+
+		response = append(response, protocol.StatusOK)
+		start := len(response)
+		response = append(response, make([]byte, size)...)
+		n, err := srv.storage.ReadFileAt(string(args[protocol.ReadFileArgsMinSize:]), response[start:], offset)
+
+		if err != nil && err != io.EOF {
+			return protocol.ErrorResponse(err)
+		}
+
+		return response[:start+n]
+	}
+
+	return protocol.ErrorResponse(protocol.ErrUnknownCommand)
+}
+
+// subscribe streams events to connection until subscription ends or connection is closed.
+func (srv *Server) subscribe(reader *bufio.Reader, writer *bufio.Writer, args []byte) {
+	if len(args) != protocol.SubscribeArgsSize {
+		_ = protocol.WriteFrame(writer, protocol.ErrorResponse(protocol.ErrMalformedFrame))
+		_ = writer.Flush()
+		return
+	}
+
+	offset := int(binary.LittleEndian.Uint64(args))
+	buffer := int(binary.LittleEndian.Uint32(args[8:]))
+
+	if buffer > maxSubscribeBuf {
+		buffer = maxSubscribeBuf
+	}
+
+	sub, err := srv.storage.Subscribe(offset, buffer, eventstorage.SlowWait)
+
+	if err != nil {
+		_ = protocol.WriteFrame(writer, protocol.ErrorResponse(err))
+		_ = writer.Flush()
+		return
+	}
+
+	defer sub.Close()
+
+	// Client sends nothing after Subscribe, so reading ends when connection is closed.
+	go func() {
+		_, _ = reader.ReadByte()
+		sub.Close()
+	}()
+
+	var response []byte
+
+	for {
+		var event eventstorage.Event
+		var ok bool
+
+		select {
+		case event, ok = <-sub.Events():
+		default:
+			// Send buffered events before waiting for new ones.
+			if err := writer.Flush(); err != nil {
+				return
+			}
+
+			event, ok = <-sub.Events()
+		}
+
+		if !ok {
+			if err := sub.Err(); err != nil {
+				_ = protocol.WriteFrame(writer, protocol.ErrorResponse(err))
+				_ = writer.Flush()
+			}
+
+			return
+		}
+
+		var eventTime int64
+
+		if !event.Time.IsZero() {
+			eventTime = event.Time.UnixNano()
+		}
+
+		response = protocol.AppendInt64(append(response[:0], protocol.StatusOK), int64(event.Offset))
+		response = append(protocol.AppendInt64(response, eventTime), event.Data...)
+
+		if err := protocol.WriteFrame(writer, response); err != nil {
+			return
+		}
+	}
+}
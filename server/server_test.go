@@ -0,0 +1,110 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/pankif/eventstorage"
+	"github.com/pankif/eventstorage/protocol"
+)
+
+func Test_Server_Pipelined(t *testing.T) {
+	storage, _ := eventstorage.New(t.TempDir())
+	srv := New(storage)
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	go func() { _ = srv.Serve(listener) }()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+		storage.Shutdown()
+	})
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+
+	if err != nil {
+		t.Fatalf("failed to connect, err: %v", err)
+	}
+
+	defer conn.Close()
+
+	writer := bufio.NewWriter(conn)
+	_ = protocol.WriteFrame(writer, []byte{protocol.CommandWrite, 'a'})
+	_ = protocol.WriteFrame(writer, []byte{protocol.CommandWrite, 'b'})
+	_ = protocol.WriteFrame(writer, []byte{protocol.CommandFlush})
+	_ = protocol.WriteFrame(writer, []byte{99})
+	_ = protocol.WriteFrame(writer, []byte{protocol.CommandRead, 1})
+	_ = writer.Flush()
+
+	expected := []struct {
+		status byte
+		size   int
+	}{
+		{protocol.StatusOK, 9},
+		{protocol.StatusOK, 9},
+		{protocol.StatusOK, 9},
+		{protocol.StatusError, len(protocol.ErrUnknownCommand.Error()) + 1},
+		{protocol.StatusError, len(protocol.ErrMalformedFrame.Error()) + 1},
+	}
+
+	for i, e := range expected {
+		response, err := protocol.ReadFrame(conn, nil)
+
+		if err != nil || response[0] != e.status || len(response) != e.size {
+			t.Errorf("Server expect response %v with status %v and size %v, got %v, err: %v", i, e.status, e.size, response, err)
+		}
+	}
+
+	if events, _ := storage.Read(3, 0); len(events) != 2 || events[1] != "b" {
+		t.Errorf("Server expect written events, got %q", events)
+	}
+}
+
+func Test_Server_SubscribeBuffer(t *testing.T) {
+	storage, _ := eventstorage.New(t.TempDir())
+	_, _ = storage.Write([]byte("a"))
+	_, _ = storage.Flush()
+	srv := New(storage)
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	go func() { _ = srv.Serve(listener) }()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+		storage.Shutdown()
+	})
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+
+	if err != nil {
+		t.Fatalf("failed to connect, err: %v", err)
+	}
+
+	defer conn.Close()
+
+	// Buffer of max uint32 is clamped by server, instead of allocating channel of this size.
+	request := make([]byte, 1+protocol.SubscribeArgsSize)
+	request[0] = protocol.CommandSubscribe
+	binary.LittleEndian.PutUint32(request[9:], 0xFFFFFFFF)
+	writer := bufio.NewWriter(conn)
+	_ = protocol.WriteFrame(writer, request)
+	_ = writer.Flush()
+
+	response, err := protocol.ReadFrame(conn, nil)
+
+	if err != nil || len(response) != protocol.SubscribeEventMinSize+1 || response[0] != protocol.StatusOK || response[len(response)-1] != 'a' {
+		t.Errorf("Subscribe expect event a, got %v, err: %v", response, err)
+	}
+}
+
+func Test_Server_Closed(t *testing.T) {
+	storage, _ := eventstorage.New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	srv := New(storage)
+	_ = srv.Close()
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+
+	if err := srv.Serve(listener); err != ErrServerClosed {
+		t.Errorf("Serve expect %v after Close, got %v", ErrServerClosed, err)
+	}
+}
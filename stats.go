@@ -0,0 +1,129 @@
+package eventstorage
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is snapshot of storage counters and gauges.
+type Stats struct {
+	Writes         uint64        // Count of written events.
+	FlushedBytes   uint64        // Size of flushed events data.
+	Reads          uint64        // Count of events read by Read and ReadTo.
+	Flushes        uint64        // Count of flushes of written events.
+	FlushErrors    uint64        // Count of failed flushes.
+	FlushTime      time.Duration // Total time of flushes.
+	LastFlushTime  time.Duration // Time of the last flush.
+	Rotations      uint64        // Count of events files rotations.
+	BufferedEvents int64         // Count of written events, which are not flushed yet.
+	Files          int           // Count of kept events files, every one is open for read.
+	FilesSize      int64         // Size of flushed events data of kept files, not compressed.
+	FirstOffset    int           // Offset of the first kept event.
+	FlushedOffset  int           // Offset after the last flushed event.
+}
+
+// counters of storage activity, they are updated atomically, so Stats does not wait for locks.
+type counters struct {
+	writes         uint64
+	flushedWrites  uint64 // Count of writes at the last flush.
+	flushedBytes   uint64
+	reads          uint64
+	flushes        uint64
+	flushErrors    uint64
+	flushNanos     uint64
+	lastFlushNanos uint64
+	rotations      uint64
+}
+
+// Stats returns snapshot of storage counters and gauges.
+func (s *EventStorage) Stats() Stats {
+	// Flushed writes are loaded first, so they never exceed loaded writes.
+	flushedWrites := atomic.LoadUint64(&s.counters.flushedWrites)
+	writes := atomic.LoadUint64(&s.counters.writes)
+	stats := Stats{
+		Writes:         writes,
+		FlushedBytes:   atomic.LoadUint64(&s.counters.flushedBytes),
+		Reads:          atomic.LoadUint64(&s.counters.reads),
+		Flushes:        atomic.LoadUint64(&s.counters.flushes),
+		FlushErrors:    atomic.LoadUint64(&s.counters.flushErrors),
+		FlushTime:      time.Duration(atomic.LoadUint64(&s.counters.flushNanos)),
+		LastFlushTime:  time.Duration(atomic.LoadUint64(&s.counters.lastFlushNanos)),
+		Rotations:      atomic.LoadUint64(&s.counters.rotations),
+		BufferedEvents: int64(writes - flushedWrites),
+	}
+
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	stats.Files = len(s.files)
+	stats.FirstOffset = s.baseOffset
+
+	for _, file := range s.files {
+		stats.FilesSize += file.size
+	}
+
+	if len(s.files) > 0 {
+		last := s.files[len(s.files)-1]
+		stats.FlushedOffset = last.firstOffset + last.count
+	}
+
+	return stats
+}
+
+// PublishExpvar publishes storage Stats into expvar by name, so they are served on /debug/vars.
+func (s *EventStorage) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return ErrExpvarExists
+	}
+
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return s.Stats()
+	}))
+
+	return nil
+}
+
+// PrometheusHandler returns handler serving storage Stats in Prometheus text format.
+func (s *EventStorage) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = s.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes storage Stats in Prometheus text format.
+func (s *EventStorage) WritePrometheus(w io.Writer) error {
+	stats := s.Stats()
+	metrics := []struct {
+		name  string
+		kind  string
+		help  string
+		value interface{}
+	}{
+		{"eventstorage_writes_total", "counter", "Count of written events.", stats.Writes},
+		{"eventstorage_flushed_bytes_total", "counter", "Size of flushed events data.", stats.FlushedBytes},
+		{"eventstorage_reads_total", "counter", "Count of events read by Read and ReadTo.", stats.Reads},
+		{"eventstorage_flushes_total", "counter", "Count of flushes of written events.", stats.Flushes},
+		{"eventstorage_flush_errors_total", "counter", "Count of failed flushes.", stats.FlushErrors},
+		{"eventstorage_flush_seconds_total", "counter", "Total time of flushes.", stats.FlushTime.Seconds()},
+		{"eventstorage_last_flush_seconds", "gauge", "Time of the last flush.", stats.LastFlushTime.Seconds()},
+		{"eventstorage_rotations_total", "counter", "Count of events files rotations.", stats.Rotations},
+		{"eventstorage_buffered_events", "gauge", "Count of written events, which are not flushed yet.", stats.BufferedEvents},
+		{"eventstorage_files", "gauge", "Count of kept events files.", stats.Files},
+		{"eventstorage_files_bytes", "gauge", "Size of flushed events data of kept files, not compressed.", stats.FilesSize},
+		{"eventstorage_first_offset", "gauge", "Offset of the first kept event.", stats.FirstOffset},
+		{"eventstorage_flushed_offset", "gauge", "Offset after the last flushed event.", stats.FlushedOffset},
+	}
+
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", metric.name, metric.help, metric.name, metric.kind, metric.name, metric.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
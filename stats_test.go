@@ -0,0 +1,86 @@
+package eventstorage
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_eventStorage_Stats(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	storage.SetWriteFileMaxSize(20)
+
+	_, _ = storage.Write([]byte("first"))
+	_, _ = storage.Write([]byte("second"))
+	stats := storage.Stats()
+
+	if stats.Writes != 2 || stats.FlushedBytes != 0 || stats.BufferedEvents != 2 {
+		t.Errorf("Stats expect 2 buffered writes, got %+v", stats)
+	}
+
+	_, _ = storage.Flush()
+	_, _ = storage.Write([]byte("third event"))
+	_, _ = storage.Read(3, 0)
+	stats = storage.Stats()
+
+	if stats.Flushes != 2 || stats.Rotations != 1 || stats.BufferedEvents != 0 || stats.FlushedBytes != 22 {
+		t.Errorf("Stats expect 2 flushes and rotation, got %+v", stats)
+	}
+
+	if stats.Reads != 3 || stats.Files != 2 || stats.FilesSize != 25 || stats.FirstOffset != 0 || stats.FlushedOffset != 3 {
+		t.Errorf("Stats expect 3 reads of 2 files, got %+v", stats)
+	}
+
+	if stats.FlushTime <= 0 || stats.LastFlushTime <= 0 || stats.LastFlushTime > stats.FlushTime {
+		t.Errorf("Stats expect flush time, got %+v", stats)
+	}
+}
+
+func Test_eventStorage_PublishExpvar(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	_, _ = storage.Write([]byte("event"))
+	// expvar names are global, so name is unique for every run of test.
+	name := "Test_eventStorage_PublishExpvar" + storage.basePath
+
+	if err := storage.PublishExpvar(name); err != nil {
+		t.Fatalf("PublishExpvar expect no error, got %v", err)
+	}
+
+	var stats Stats
+
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &stats); err != nil || stats.Writes != 1 {
+		t.Errorf("expvar expect stats with one write, got %+v, err: %v", stats, err)
+	}
+
+	if err := storage.PublishExpvar(name); err != ErrExpvarExists {
+		t.Errorf("PublishExpvar expect %v, got %v", ErrExpvarExists, err)
+	}
+}
+
+func Test_eventStorage_PrometheusHandler(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+	_, _ = storage.Write([]byte("event"))
+
+	recorder := httptest.NewRecorder()
+	storage.PrometheusHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	for _, line := range []string{
+		"# TYPE eventstorage_writes_total counter\neventstorage_writes_total 1\n",
+		"# TYPE eventstorage_buffered_events gauge\neventstorage_buffered_events 1\n",
+		"eventstorage_files 1\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics expect %q, got %q", line, body)
+		}
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("metrics expect Prometheus text content type, got %q", contentType)
+	}
+}
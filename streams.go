@@ -0,0 +1,236 @@
+package eventstorage
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage hosts named streams under one base path. Every stream is EventStorage
+// with its own subdirectory and registry, it is created on the first write.
+type Storage struct {
+	basePath      string
+	fsys          FS                       // File system of streams, set by options.
+	options       []Option                 // Options of every stream.
+	streams       map[string]*EventStorage // Opened streams.
+	locker        sync.Mutex               // Opened streams lock.
+	autoFlushTime time.Duration            // Flush period of all streams, 0 - disable.
+	turnedOff     chan bool
+}
+
+// NewStorage returns manager of streams in base path, options are applied to every stream.
+func NewStorage(basePath string, options ...Option) (*Storage, error) {
+	fsys := optionsFS(options)
+
+	if err := fsys.MkdirAll(basePath, 0755); err != nil {
+		return nil, errors.New("Failed to create base path: " + err.Error())
+	}
+
+	return &Storage{
+		basePath:  basePath,
+		fsys:      fsys,
+		options:   options,
+		streams:   make(map[string]*EventStorage),
+		turnedOff: make(chan bool, 1),
+	}, nil
+}
+
+// Stream returns named stream, the stream is created when it does not exist.
+func (st *Storage) Stream(name string) (*EventStorage, error) {
+	return st.stream(name, true)
+}
+
+// Write appends event into named stream and returns its offset in the stream.
+func (st *Storage) Write(name string, data []byte) (offset int, err error) {
+	s, err := st.stream(name, true)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Write(data)
+}
+
+// Read reads up to count events of named stream starting from offset.
+// It returns ErrStreamNotFound for stream without writes.
+func (st *Storage) Read(name string, count int, offset int) ([]string, error) {
+	s, err := st.stream(name, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Read(count, offset)
+}
+
+// Flush flushes all opened streams, it returns the first error.
+func (st *Storage) Flush() (err error) {
+	for _, s := range st.opened() {
+		if _, flushErr := s.Flush(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+	}
+
+	return err
+}
+
+// SetAutoFlushTime flushes all streams every period by one scheduler.
+func (st *Storage) SetAutoFlushTime(period time.Duration) error {
+	if period <= 0 {
+		return ErrAutoFlushTimeTooLow
+	}
+
+	st.locker.Lock()
+	defer st.locker.Unlock()
+
+	if st.autoFlushTime != 0 {
+		return ErrAutoFlushTimeAlreadySet
+	}
+
+	st.autoFlushTime = period
+
+	go func() {
+		for range time.Tick(period) {
+			select {
+			case <-st.turnedOff:
+				return
+			default:
+			}
+
+			_ = st.Flush()
+		}
+	}()
+
+	return nil
+}
+
+// Streams returns sorted names of all streams in base path, including not opened ones.
+func (st *Storage) Streams() ([]string, error) {
+	entries, err := st.fsys.ReadDir(st.basePath)
+
+	if err != nil {
+		return nil, errors.New("Failed to list streams: " + err.Error())
+	}
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if _, err = st.fsys.Stat(st.getStreamPath(entry.Name()) + string(os.PathSeparator) + registryFileName); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// DeleteStream shuts down named stream and removes its directory.
+func (st *Storage) DeleteStream(name string) error {
+	if !isValidStreamName(name) {
+		return ErrInvalidStreamName
+	}
+
+	st.locker.Lock()
+	defer st.locker.Unlock()
+
+	if s, ok := st.streams[name]; ok {
+		s.Shutdown()
+		delete(st.streams, name)
+	} else if _, err := st.fsys.Stat(st.getStreamPath(name)); os.IsNotExist(err) {
+		return ErrStreamNotFound
+	}
+
+	if err := st.fsys.RemoveAll(st.getStreamPath(name)); err != nil {
+		return errors.New("Failed to remove stream: " + err.Error())
+	}
+
+	return nil
+}
+
+// Shutdown stops flush scheduler and shuts down all opened streams.
+func (st *Storage) Shutdown() {
+	st.locker.Lock()
+	defer st.locker.Unlock()
+
+	select {
+	case <-st.turnedOff:
+	default:
+		close(st.turnedOff)
+	}
+
+	for name, s := range st.streams {
+		s.Shutdown()
+		delete(st.streams, name)
+	}
+}
+
+// stream returns opened stream or opens it, stream without directory is created only with create.
+func (st *Storage) stream(name string, create bool) (*EventStorage, error) {
+	if !isValidStreamName(name) {
+		return nil, ErrInvalidStreamName
+	}
+
+	st.locker.Lock()
+	defer st.locker.Unlock()
+
+	if s, ok := st.streams[name]; ok {
+		return s, nil
+	}
+
+	select {
+	case <-st.turnedOff:
+		return nil, ErrStorageShutdown
+	default:
+	}
+
+	path := st.getStreamPath(name)
+
+	if _, err := st.fsys.Stat(path); os.IsNotExist(err) && !create {
+		return nil, ErrStreamNotFound
+	}
+
+	if err := st.fsys.MkdirAll(path, 0755); err != nil {
+		return nil, errors.New("Failed to create stream: " + err.Error())
+	}
+
+	s, err := New(path, st.options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	st.streams[name] = s
+
+	return s, nil
+}
+
+// opened returns copy of opened streams list.
+func (st *Storage) opened() []*EventStorage {
+	st.locker.Lock()
+	defer st.locker.Unlock()
+
+	streams := make([]*EventStorage, 0, len(st.streams))
+
+	for _, s := range st.streams {
+		streams = append(streams, s)
+	}
+
+	return streams
+}
+
+func (st *Storage) getStreamPath(name string) string {
+	return st.basePath + string(os.PathSeparator) + name
+}
+
+// isValidStreamName allows names, which are directory names inside base path.
+func isValidStreamName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`+"\x00")
+}
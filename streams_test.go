@@ -0,0 +1,88 @@
+package eventstorage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Storage_Streams(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := NewStorage(path, WithFormat(FormatFramed))
+
+	if _, err := storage.Read("orders", 1, 0); err != ErrStreamNotFound {
+		t.Errorf("Storage Read expect %v before write, got %v", ErrStreamNotFound, err)
+	}
+
+	_, _ = storage.Write("orders", []byte("order 1"))
+	offset, _ := storage.Write("orders", []byte("order 2"))
+	_, _ = storage.Write("payments", []byte("payment 1"))
+
+	if offset != 1 {
+		t.Errorf("Storage Write expect offset 1 in stream, got %v", offset)
+	}
+
+	if names, err := storage.Streams(); err != nil || !reflect.DeepEqual(names, []string{"orders", "payments"}) {
+		t.Errorf("Storage Streams expect orders and payments, got %v, err: %v", names, err)
+	}
+
+	_ = storage.Flush()
+	storage.Shutdown()
+
+	storage, _ = NewStorage(path)
+	t.Cleanup(storage.Shutdown)
+
+	if events, err := storage.Read("orders", 3, 0); err != nil || !reflect.DeepEqual(events, []string{"order 1", "order 2"}) {
+		t.Errorf("Storage Read expect orders after restart, got %q, err: %v", events, err)
+	}
+
+	if stream, _ := storage.Stream("payments"); stream.codec.format != FormatFramed {
+		t.Errorf("Storage expect stream format %v, got %v", FormatFramed, stream.codec.format)
+	}
+
+	if err := storage.DeleteStream("orders"); err != nil {
+		t.Errorf("Storage DeleteStream failed, err: %v", err)
+	}
+
+	if names, _ := storage.Streams(); !reflect.DeepEqual(names, []string{"payments"}) {
+		t.Errorf("Storage Streams expect payments after delete, got %v", names)
+	}
+
+	if err := storage.DeleteStream("orders"); err != ErrStreamNotFound {
+		t.Errorf("Storage DeleteStream expect %v, got %v", ErrStreamNotFound, err)
+	}
+}
+
+func Test_Storage_InvalidStreamName(t *testing.T) {
+	storage, _ := NewStorage(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	for _, name := range []string{"", ".", "..", "a/b"} {
+		if _, err := storage.Write(name, []byte("event")); err != ErrInvalidStreamName {
+			t.Errorf("Storage Write expect %v for %q, got %v", ErrInvalidStreamName, name, err)
+		}
+	}
+}
+
+func Test_Storage_SetAutoFlushTime(t *testing.T) {
+	storage, _ := NewStorage(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	if err := storage.SetAutoFlushTime(10 * time.Millisecond); err != nil {
+		t.Fatalf("Storage SetAutoFlushTime failed, err: %v", err)
+	}
+
+	if err := storage.SetAutoFlushTime(time.Second); err != ErrAutoFlushTimeAlreadySet {
+		t.Errorf("Storage SetAutoFlushTime expect %v, got %v", ErrAutoFlushTimeAlreadySet, err)
+	}
+
+	_, _ = storage.Write("orders", []byte("order"))
+	_, _ = storage.Write("payments", []byte("payment"))
+	time.Sleep(50 * time.Millisecond)
+
+	for _, name := range []string{"orders", "payments"} {
+		if events, err := storage.Read(name, 1, 0); err != nil || len(events) != 1 {
+			t.Errorf("Storage SetAutoFlushTime expect flushed %v, got %q, err: %v", name, events, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package eventstorage
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// SlowPolicy defines subscription behavior, when its buffer is full.
+type SlowPolicy int
+
+const (
+	SlowWait  SlowPolicy = iota // Subscription waits for subscriber, not delivered events are read from files later.
+	SlowClose                   // Subscription is closed with ErrSubscriberTooSlow.
+)
+
+// Event is an event with its offset in storage.
+type Event struct {
+	Offset int
+	Data   []byte
+	Time   time.Time // Write time of event, with timestamps enabled.
+}
+
+// Subscription delivers stored events and then new events after they are flushed.
+type Subscription struct {
+	events    chan Event
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// Subscribe returns subscription delivering events starting from offset.
+// Buffer is the capacity of events channel, policy defines what to do when buffer is full.
+// SlowClose requires buffer of at least 1 event, without buffer the first event would end subscription.
+func (s *EventStorage) Subscribe(fromOffset int, buffer int, policy SlowPolicy) (*Subscription, error) {
+	if buffer < 0 || buffer < 1 && policy == SlowClose {
+		return nil, ErrInvalidSubscribeBuffer
+	}
+
+	sub := &Subscription{
+		events:  make(chan Event, buffer),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go sub.run(s, s.Cursor(fromOffset), policy)
+
+	return sub, nil
+}
+
+// Events returns channel of events, it is closed when subscription ends.
+func (sub *Subscription) Events() <-chan Event {
+	return sub.events
+}
+
+// Err returns the reason of subscription end, it is valid after events channel closed.
+// Nil means that subscription was closed by Close.
+func (sub *Subscription) Err() error {
+	return sub.err
+}
+
+// Close cancels subscription and waits for it to stop.
+func (sub *Subscription) Close() {
+	sub.closeOnce.Do(func() {
+		close(sub.done)
+	})
+
+	<-sub.stopped
+}
+
+func (sub *Subscription) run(s *EventStorage, cursor *Cursor, policy SlowPolicy) {
+	defer func() {
+		_ = cursor.Close()
+		close(sub.events)
+		close(sub.stopped)
+	}()
+
+	for {
+		flushed := s.flushedSignal()
+		offset := cursor.Offset()
+		data, err := cursor.Next()
+
+		if err == io.EOF {
+			select {
+			case <-flushed:
+				continue
+			case <-sub.done:
+				return
+			case <-s.turnedOff:
+				sub.err = ErrStorageShutdown
+				return
+			}
+		}
+
+		if err != nil {
+			sub.err = err
+			return
+		}
+
+		event := Event{Offset: offset, Data: data, Time: cursor.Time()}
+
+		if policy == SlowClose {
+			select {
+			case sub.events <- event:
+			case <-sub.done:
+				return
+			default:
+				sub.err = ErrSubscriberTooSlow
+				return
+			}
+
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+// flushedSignal returns channel, which is closed on the next flush.
+func (s *EventStorage) flushedSignal() <-chan struct{} {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	return s.flushed
+}
+
+// notifyFlushed wakes up subscriptions waiting for new events, it must be called under filesLocker.
+func (s *EventStorage) notifyFlushed() {
+	if s.flushed != nil {
+		close(s.flushed)
+	}
+
+	s.flushed = make(chan struct{})
+}
@@ -0,0 +1,115 @@
+package eventstorage
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_Subscription_HistoryAndLive(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	storage.SetWriteFileMaxSize(50)
+	t.Cleanup(storage.Shutdown)
+
+	for i := 0; i < 5; i++ {
+		_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+	}
+
+	_, _ = storage.Flush()
+
+	sub, _ := storage.Subscribe(2, 1, SlowWait)
+	t.Cleanup(sub.Close)
+
+	go func() {
+		for i := 5; i < 20; i++ {
+			_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+			_, _ = storage.Flush()
+		}
+	}()
+
+	for i := 2; i < 20; i++ {
+		select {
+		case event := <-sub.Events():
+			if event.Offset != i || string(event.Data) != "event "+strconv.Itoa(i) {
+				t.Errorf("Subscription expect event %v, got %v %q", i, event.Offset, event.Data)
+				return
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Subscription expect event %v, got timeout", i)
+			return
+		}
+	}
+}
+
+func Test_Subscription_Close(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	sub, _ := storage.Subscribe(0, 0, SlowWait)
+	sub.Close()
+
+	if _, opened := <-sub.Events(); opened || sub.Err() != nil {
+		t.Errorf("Subscription Close expect closed events without error, got %v", sub.Err())
+	}
+}
+
+func Test_Subscription_SlowClose(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	for i := 0; i < 3; i++ {
+		_, _ = storage.Write([]byte("event"))
+	}
+
+	_, _ = storage.Flush()
+
+	sub, _ := storage.Subscribe(0, 1, SlowClose)
+	time.Sleep(50 * time.Millisecond)
+
+	events := 0
+
+	for range sub.Events() {
+		events++
+	}
+
+	if events != 1 || sub.Err() != ErrSubscriberTooSlow {
+		t.Errorf("Subscription SlowClose expect 1 buffered event and error, got %v, err: %v", events, sub.Err())
+	}
+}
+
+func Test_Subscription_StorageShutdown(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	sub, _ := storage.Subscribe(0, 0, SlowWait)
+	storage.Shutdown()
+
+	select {
+	case <-sub.Events():
+	case <-time.After(time.Second):
+		t.Errorf("Subscription expect end on storage shutdown")
+		return
+	}
+
+	if sub.Err() != ErrStorageShutdown {
+		t.Errorf("Subscription expect shutdown error, got %v", sub.Err())
+	}
+}
+
+func Test_Subscription_InvalidBuffer(t *testing.T) {
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	tests := []struct {
+		buffer int
+		policy SlowPolicy
+	}{
+		{-1, SlowWait},
+		{-1, SlowClose},
+		{0, SlowClose},
+	}
+
+	for _, tt := range tests {
+		if sub, err := storage.Subscribe(0, tt.buffer, tt.policy); sub != nil || err != ErrInvalidSubscribeBuffer {
+			t.Errorf("Subscribe expect ErrInvalidSubscribeBuffer for buffer %v and policy %v, got %v", tt.buffer, tt.policy, err)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package eventstorage
+
+import "os"
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	if err = dir.Sync(); err != nil {
+		_ = dir.Close()
+		return err
+	}
+
+	return dir.Close()
+}
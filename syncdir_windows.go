@@ -0,0 +1,6 @@
+package eventstorage
+
+// syncDir does nothing, windows does not support sync of directories.
+func syncDir(path string) error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package eventstorage
+
+import (
+	"sort"
+	"time"
+)
+
+// OffsetForTime returns offset of the first event written at or after t,
+// it is the offset of the next event to write if there are no such events.
+func (s *EventStorage) OffsetForTime(t time.Time) (int, error) {
+	if !s.codec.timestamps {
+		return 0, ErrTimestampsDisabled
+	}
+
+	// Read locker is taken before lookup, so retention and compression do not close the found file.
+	s.read.locker.Lock()
+	defer s.read.locker.Unlock()
+
+	target := t.UnixNano()
+	file, found := s.findFileByTime(target)
+
+	if !found {
+		return s.flushedOffset(), nil
+	}
+
+	block := sort.Search(len(file.times), func(i int) bool {
+		return file.times[i] > target
+	}) - 1
+
+	if block < 0 {
+		block = 0
+	}
+
+	fileOffset := block * indexInterval
+
+	if err := s.read.reader.seek(file, fileOffset); err != nil {
+		return 0, s.read.reader.committedErr(err)
+	}
+
+	for ; fileOffset < file.count; fileOffset++ {
+		if _, err := s.read.reader.next(); err != nil {
+			return 0, s.read.reader.committedErr(err)
+		}
+
+		if s.read.reader.time >= target {
+			return file.firstOffset + fileOffset, nil
+		}
+	}
+
+	return file.firstOffset + file.count, nil
+}
+
+// ReadSince reads up to count events written at or after t.
+func (s *EventStorage) ReadSince(t time.Time, count int) ([]string, error) {
+	offset, err := s.OffsetForTime(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Read(count, offset)
+}
+
+// findFileByTime returns copy of the last events file, which first event is written not after target,
+// or the first events file if all events are written after target.
+func (s *EventStorage) findFileByTime(target int64) (eventsFile, bool) {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	indexed := len(s.files)
+
+	for indexed > 0 && len(s.files[indexed-1].times) == 0 {
+		indexed--
+	}
+
+	if indexed == 0 {
+		return eventsFile{}, false
+	}
+
+	i := sort.Search(indexed, func(i int) bool {
+		return s.files[i].times[0] > target
+	}) - 1
+
+	if i < 0 {
+		i = 0
+	}
+
+	return *s.files[i], true
+}
+
+// flushedOffset returns offset of the event after the last flushed one.
+func (s *EventStorage) flushedOffset() int {
+	s.filesLocker.RLock()
+	defer s.filesLocker.RUnlock()
+
+	last := s.files[len(s.files)-1]
+
+	return last.firstOffset + last.count
+}
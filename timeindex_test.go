@@ -0,0 +1,131 @@
+package eventstorage
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_eventStorage_OffsetForTime(t *testing.T) {
+	path := t.TempDir()
+	storage, _ := New(path, WithFormat(FormatFramed), WithTimestamps())
+	storage.SetWriteFileMaxSize(30 * KB)
+
+	batches := []int{indexInterval + 10, indexInterval * 2, 5}
+	starts := make([]time.Time, len(batches))
+	offsets := make([]int, len(batches))
+	total := 0
+
+	for i, size := range batches {
+		time.Sleep(5 * time.Millisecond)
+		starts[i] = time.Now()
+		offsets[i] = total
+
+		for j := 0; j < size; j++ {
+			_, _ = storage.Write([]byte("event " + strconv.Itoa(total)))
+			total++
+		}
+
+		_, _ = storage.Flush()
+	}
+
+	storage.Shutdown()
+	storage, err := New(path)
+
+	if err != nil {
+		t.Errorf("OffsetForTime failed to open, err: %v", err)
+		return
+	}
+
+	t.Cleanup(storage.Shutdown)
+
+	if len(storage.files) < 2 {
+		t.Errorf("OffsetForTime expect rotated files, got %v", len(storage.files))
+	}
+
+	for i, start := range starts {
+		if offset, err := storage.OffsetForTime(start); err != nil || offset != offsets[i] {
+			t.Errorf("OffsetForTime expect offset %v for batch %v, got %v, err: %v", offsets[i], i, offset, err)
+		}
+	}
+
+	if offset, _ := storage.OffsetForTime(time.Time{}); offset != 0 {
+		t.Errorf("OffsetForTime expect the first offset for old time, got %v", offset)
+	}
+
+	if offset, _ := storage.OffsetForTime(time.Now().Add(time.Hour)); offset != total {
+		t.Errorf("OffsetForTime expect the next offset for future time, got %v", offset)
+	}
+
+	events, err := storage.ReadSince(starts[2], 10)
+
+	if err != nil || len(events) != batches[2] || events[0] != "event "+strconv.Itoa(offsets[2]) {
+		t.Errorf("ReadSince incorrect events %q, err: %v", events, err)
+	}
+
+	cursor := storage.Cursor(offsets[1])
+	defer cursor.Close()
+	_, _ = cursor.Next()
+
+	if cursor.Time().Before(starts[1]) || cursor.Time().After(starts[2]) {
+		t.Errorf("Cursor Time expect time of the second batch, got %v", cursor.Time())
+	}
+}
+
+func Test_eventStorage_OffsetForTimeRetention(t *testing.T) {
+	storage, _ := New(t.TempDir(), WithFormat(FormatFramed), WithTimestamps())
+	t.Cleanup(storage.Shutdown)
+	storage.SetWriteFileMaxSize(100)
+	_ = storage.SetRetention(Retention{MaxFiles: 2})
+	_, _ = storage.Write([]byte("event"))
+	_, _ = storage.Flush()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 2000; i++ {
+			_, _ = storage.Write([]byte("event " + strconv.Itoa(i)))
+		}
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		// The oldest file is removed by retention concurrently.
+		if _, err := storage.OffsetForTime(time.Time{}); err != nil && err != ErrOffsetRemoved {
+			t.Fatalf("OffsetForTime expect offset of kept file, got err: %v", err)
+		}
+	}
+}
+
+func Test_eventStorage_TimestampsRequireFramed(t *testing.T) {
+	if _, err := New(t.TempDir(), WithTimestamps()); err != ErrTimestampsRequireFramed {
+		t.Errorf("TimestampsRequireFramed expect error, got %v", err)
+	}
+
+	storage, _ := New(t.TempDir())
+	t.Cleanup(storage.Shutdown)
+
+	if _, err := storage.OffsetForTime(time.Now()); err != ErrTimestampsDisabled {
+		t.Errorf("OffsetForTime expect disabled timestamps error, got %v", err)
+	}
+}
+
+func Test_eventStorage_TimestampsWithChecksums(t *testing.T) {
+	storage, _ := New(t.TempDir(), WithFormat(FormatFramed), WithChecksums(), WithTimestamps())
+	t.Cleanup(storage.Shutdown)
+
+	_, _ = storage.Write([]byte("first"))
+	_, _ = storage.Write([]byte("second"))
+	_, _ = storage.Flush()
+
+	if events, err := storage.Read(2, 0); err != nil || len(events) != 2 || events[1] != "second" {
+		t.Errorf("TimestampsWithChecksums read incorrect events %q, err: %v", events, err)
+	}
+}
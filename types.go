@@ -3,56 +3,136 @@ package eventstorage
 import (
 	"bytes"
 	"errors"
-	"os"
-	"strings"
+	"io"
 	"sync"
 	"time"
 )
 
 const (
-	LineBreak        byte = '\n'
-	registryFileName      = "events_files.registry"
+	LineBreak            byte = '\n'
+	registryFileName          = "events_files.registry"
+	registryHeader            = '#' // Registry lines with this prefix are storage settings, not events files.
+	consumersFileName         = "consumers.offsets"
+	lockFileName              = "events.lock"
+	batchFileName             = "events.batch"
+	partitionsFileName        = "partitions.count"
+	partitionDirTemplate      = "partition.%d"
+	tmpFileSuffix             = ".tmp"
+	indexFileSuffix           = ".idx"
+	compressedFileSuffix      = ".gz"
+	blocksFileSuffix          = ".blocks"
+	frameHeaderSize           = 4       // Size of framed event header, data length as uint32.
+	checksumSize              = 4       // Size of event checksum, crc32 of event data or of framed event length.
+	timestampSize             = 8       // Size of event write time, unix nanoseconds as int64.
+	frameBatchFlag            = 1 << 31 // Flag of framed event length, the event is followed by the next event of its batch.
+	frameLengthMask           = frameBatchFlag - 1
 )
 
 const (
-	KB           int64 = 1 << 10
-	MB           int64 = 1 << 20
-	readBufLimit       = 32 * KB
+	FormatLines  Format = iota // Events separated by LineBreak, so event data must not contain it.
+	FormatFramed               // Every event prefixed by its data length, event data may contain any bytes.
+)
+
+const (
+	KB             int64 = 1 << 10
+	MB             int64 = 1 << 20
+	readBufLimit         = 32 * KB
+	indexInterval        = 1024 // Position of every N-th event of file saved into index.
+	indexEntrySize       = 8    // Size of one index entry value, position or write time as int64.
 )
 
 var (
 	ErrAutoFlushTimeAlreadySet = errors.New("autoFlushTime already set")
 	ErrAutoFlushTimeTooLow     = errors.New("autoFlushTime too low value")
+	ErrUnknownFormat           = errors.New("unknown events format")
+	ErrChecksumMismatch        = errors.New("checksum mismatch")
+	ErrTimestampsRequireFramed = errors.New("timestamps require framed format")
+	ErrTimestampsDisabled      = errors.New("timestamps disabled")
+	ErrLineBreakInEvent        = errors.New("event of lines format contains line break")
+	ErrCursorClosed            = errors.New("cursor closed")
+	ErrOffsetRemoved           = errors.New("offset removed by retention")
+	ErrInvalidConsumerName     = errors.New("consumer name must be not empty and without spaces")
+	ErrStorageShutdown         = errors.New("storage shutdown")
+	ErrStorageLocked           = errors.New("storage is locked by another writer")
+	ErrReadOnly                = errors.New("storage opened read-only")
+	ErrNoEventsFiles           = errors.New("registry has no events files")
+	ErrInvalidStreamName       = errors.New("stream name must be not empty directory name")
+	ErrStreamNotFound          = errors.New("stream not found")
+	ErrPartitionsCount         = errors.New("wrong partitions count")
+	ErrFileNotKept             = errors.New("events file is not kept")
+	ErrExpvarExists            = errors.New("expvar with this name already exists")
+	ErrSubscriberTooSlow       = errors.New("subscriber too slow")
+	ErrInvalidSubscribeBuffer  = errors.New("subscription buffer must be not negative and at least 1 with SlowClose")
+	ErrSyncIntervalAlreadySet  = errors.New("syncInterval already set")
+	ErrSyncIntervalTooLow      = errors.New("syncInterval too low value")
 	eventsFileNameTemplate     = "events.%d"
 )
 
+// Format is the way events are laid out in events files.
+type Format int
+
 type EventStorage struct {
-	basePath      string   // Root path of events storage.
-	filesRegistry *os.File // File with list of exists events files.
-	write         *write   // Variables for write events.
-	read          *read    // Variables for read events.
-	turnedOff     chan bool
+	counters        counters       // Activity counters, the first field to keep them aligned for atomic operations.
+	basePath        string         // Root path of events storage.
+	fsys            FS             // File system of storage files.
+	codec           codec          // Events format and checksums setting, saved in registry.
+	filesRegistry   File           // File with list of exists events files.
+	files           []*eventsFile  // Events files in registry order.
+	filesLocker     sync.RWMutex   // Files list and files counters lock, shared between write and read.
+	write           *write         // Variables for write events.
+	read            *read          // Variables for read events.
+	baseOffset      int            // Offset of the first event of the first kept events file.
+	recovery        Recovery       // Report of crash recovery on open.
+	flushed         chan struct{}  // Closed and replaced on every flush, under filesLocker.
+	consumers       map[string]int // Committed offsets of named consumers.
+	consumersLocker sync.Mutex     // Consumers offsets lock.
+	lockFile        io.Closer      // Locked by writer, so only one writer uses base path.
+	readOnly        bool           // Opened by OpenReadOnly, files are never created or written.
+	turnedOff       chan bool
 }
 
 type write struct {
-	file           *os.File      // Current file to write events
-	fileSize       int64         // Size of current events file
-	fileMaxSize    int64         // Size of events file for create a new file
-	locker         sync.Mutex    // Write common variables lock to avoid race condition.
-	buf            *bytes.Buffer // For collect data before flush it to file.
-	insertsCount   int           // Count of written events, from last data flush
-	autoFlushCount int           // Auto flush after N count of events insert, 0 - disable.
-	autoFlushTime  time.Duration // Auto flush every N seconds, 0 - disable.
+	file             File           // Current file to write events
+	indexFile        File           // Index of current file to write events positions
+	fileSize         int64          // Size of current events file
+	fileMaxSize      int64          // Size of events file for create a new file
+	fileEvents       int            // Count of events in current file, including not flushed
+	offset           int            // Offset of the next event to write
+	positions        []int64        // Index positions of not flushed events
+	times            []int64        // Index write times of not flushed events, with timestamps enabled
+	lastTime         int64          // Write time of the last event, with timestamps enabled
+	locker           contextLocker  // Write common variables lock to avoid race condition.
+	buf              *bytes.Buffer  // For collect data before flush it to file.
+	insertsCount     int            // Count of written events, from last data flush
+	dataSize         int64          // Size of written events data, from last data flush
+	autoFlushCount   int            // Auto flush after N count of events insert, 0 - disable.
+	autoFlushTime    time.Duration  // Auto flush every N seconds, 0 - disable.
+	durability       Durability     // When flushed events are synced to disk.
+	syncInterval     time.Duration  // Sync period for DurabilityInterval.
+	dirty            bool           // Flushed events are not synced yet, for DurabilityInterval.
+	retention        Retention      // Limits of kept events files.
+	compression      Compression    // Compression of sealed events files.
+	compressErr      error          // The last error of background compression.
+	compressing      sync.WaitGroup // Running background compressions.
+	compressingFiles map[int]bool   // Numbers of files being compressed in background.
+	shuttingDown     bool           // Shutdown started, so no new background compression is started.
+	batchMarked      bool           // Batch file was written after open, so it exists.
 }
 
 type read struct {
-	locker        sync.Mutex       // Read common variables lock to avoid race condition.
-	buf           *strings.Builder // For collect one event data before append to events slice.
-	readBuf       []byte           // For read data from file.
-	seekOffset    int64            // Current file read offset.
-	eventsSaved   int              // Count of events to return.
-	eventsCount   int              // Count of read events, for check offset.
-	readableFiles readableFiles    // Map of events files opened for read.
+	locker contextLocker // Read common variables lock to avoid race condition.
+	reader *recordReader // For read events from files, uses shared read buffer.
 }
 
-type readableFiles map[int]*os.File
+type eventsFile struct {
+	number      int     // Number of events file, part of file name.
+	reader      File    // Events file opened for read.
+	firstOffset int     // Offset of the first file event in whole storage.
+	count       int     // Count of flushed events in file.
+	size        int64   // Size of flushed events data in file.
+	positions   []int64 // Sparse index, position of every indexInterval-th event in file.
+	compressed  bool    // Sealed file compressed by gzip, size and positions are of not compressed data.
+	blocks      []int64 // Compressed positions of gzip members of index blocks, nil if not known.
+	times       []int64 // Time index, write time of every indexInterval-th event, with timestamps enabled.
+	lastTime    int64   // Write time of the last event, if known.
+}